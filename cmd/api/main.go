@@ -10,10 +10,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/azcov/bookcabin_test/internal/chaos"
 	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/provider"
 	"github.com/azcov/bookcabin_test/internal/service"
 	"github.com/azcov/bookcabin_test/internal/transport/api"
+	"github.com/azcov/bookcabin_test/pkg/clock"
 	"github.com/azcov/bookcabin_test/pkg/logger"
+	"github.com/azcov/bookcabin_test/pkg/tracing"
 	"github.com/joho/godotenv"
 )
 
@@ -28,9 +32,24 @@ func main() {
 	cfg := config.NewConfig()
 	config.LoadConfig(cfg)
 	logger.Info("Loading config", "cfg", cfg)
-	svc := service.NewFlightService(*cfg)
-	h := api.NewHandler(svc)
-	r := api.NewRouter(h)
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatalf("tracing: %s\n", err)
+	}
+	clk := clock.New()
+	chaosInjector := chaos.NewInjector(cfg.Chaos, nil)
+	airlineProvider := provider.NewAirlineProvider(chaosInjector, clk, cfg.Hedge)
+	searchCache := service.NewSearchCache(cfg.Cache, clk)
+	svc := service.NewFlightServiceWithCache(*cfg, airlineProvider, searchCache, clk)
+	refSvc := service.NewReferenceService()
+	chaosSvc := service.NewChaosService(chaosInjector)
+	warmer := service.NewWarmer(cfg.Warmup, svc, airlineProvider, clk)
+	cacheSvc := service.NewCacheAdminService(searchCache, warmer, clk)
+	h := api.NewHandler(svc, refSvc, chaosSvc, cacheSvc)
+	r := api.NewRouter(h, api.RouterConfig{
+		ServiceName: cfg.Tracing.ServiceName,
+		AdminToken:  cfg.Http.AdminToken,
+	})
 
 	// Start http.Server and graceful shutdown
 	srv := &http.Server{
@@ -41,6 +60,10 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	warmupCtx, stopWarmup := context.WithCancel(context.Background())
+	defer stopWarmup()
+	warmer.Start(warmupCtx)
+
 	go func() {
 		log.Printf("Starting server on %s", srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -52,6 +75,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopWarmup()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -59,5 +83,13 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	if err := svc.Close(ctx); err != nil {
+		log.Printf("Background refreshes cancelled: %v", err)
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Tracing shutdown: %v", err)
+	}
+
 	log.Println("Server exiting")
 }
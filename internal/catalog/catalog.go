@@ -0,0 +1,167 @@
+package catalog
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/azcov/bookcabin_test/internal/domain"
+)
+
+type BodyType string
+
+const (
+	BodyTypeNarrow BodyType = "narrow_body"
+	BodyTypeWide   BodyType = "wide_body"
+)
+
+// Airline is the reference data of a carrier.
+type Airline struct {
+	IATA     string `json:"iata"`
+	ICAO     string `json:"icao"`
+	Name     string `json:"name"`
+	LogoKey  string `json:"logo_key"`
+	Alliance string `json:"alliance,omitempty"`
+	LowCost  bool   `json:"low_cost"`
+	// DefaultAircraft is the IATA equipment code assumed when a provider does
+	// not report the aircraft.
+	DefaultAircraft string `json:"default_aircraft"`
+}
+
+// Aircraft is the reference data of an aircraft type. SeatPitchInch is the
+// typical economy seat pitch.
+type Aircraft struct {
+	IATA          string   `json:"iata"`
+	Model         string   `json:"model"`
+	BodyType      BodyType `json:"body_type"`
+	SeatPitchInch int      `json:"seat_pitch_inch"`
+	// Aliases are the model names providers use for this type.
+	Aliases []string `json:"-"`
+}
+
+var (
+	airlines = []Airline{
+		{IATA: "GA", ICAO: "GIA", Name: "Garuda Indonesia", LogoKey: "garuda_indonesia", Alliance: "SkyTeam", DefaultAircraft: "738"},
+		{IATA: "ID", ICAO: "BTK", Name: "Batik Air", LogoKey: "batik_air", DefaultAircraft: "320"},
+		{IATA: "JT", ICAO: "LNI", Name: "Lion Air", LogoKey: "lion_air", LowCost: true, DefaultAircraft: "739"},
+		{IATA: "QZ", ICAO: "AWQ", Name: "AirAsia", LogoKey: "airasia", LowCost: true, DefaultAircraft: "320"},
+		{IATA: "QG", ICAO: "CTV", Name: "Citilink", LogoKey: "citilink", LowCost: true, DefaultAircraft: "320"},
+		{IATA: "IU", ICAO: "SJV", Name: "Super Air Jet", LogoKey: "super_air_jet", LowCost: true, DefaultAircraft: "321"},
+		{IATA: "IW", ICAO: "WON", Name: "Wings Air", LogoKey: "wings_air", LowCost: true, DefaultAircraft: "AT7"},
+	}
+
+	aircraft = []Aircraft{
+		{IATA: "320", Model: "Airbus A320", BodyType: BodyTypeNarrow, SeatPitchInch: 29, Aliases: []string{"A320", "Airbus A320-200"}},
+		{IATA: "32N", Model: "Airbus A320neo", BodyType: BodyTypeNarrow, SeatPitchInch: 29, Aliases: []string{"A320neo"}},
+		{IATA: "321", Model: "Airbus A321", BodyType: BodyTypeNarrow, SeatPitchInch: 29, Aliases: []string{"A321", "Airbus A321-200"}},
+		{IATA: "333", Model: "Airbus A330-300", BodyType: BodyTypeWide, SeatPitchInch: 32, Aliases: []string{"A330-300"}},
+		{IATA: "339", Model: "Airbus A330-900neo", BodyType: BodyTypeWide, SeatPitchInch: 32, Aliases: []string{"A330-900", "A330neo"}},
+		{IATA: "737", Model: "Boeing 737", BodyType: BodyTypeNarrow, SeatPitchInch: 30, Aliases: []string{"B737"}},
+		{IATA: "738", Model: "Boeing 737-800", BodyType: BodyTypeNarrow, SeatPitchInch: 30, Aliases: []string{"B737-800", "737-800"}},
+		{IATA: "739", Model: "Boeing 737-900ER", BodyType: BodyTypeNarrow, SeatPitchInch: 29, Aliases: []string{"Boeing 737-900", "B737-900ER"}},
+		{IATA: "7M8", Model: "Boeing 737 MAX 8", BodyType: BodyTypeNarrow, SeatPitchInch: 29, Aliases: []string{"737 MAX 8", "B737 MAX 8"}},
+		{IATA: "77W", Model: "Boeing 777-300ER", BodyType: BodyTypeWide, SeatPitchInch: 32, Aliases: []string{"B777-300ER"}},
+		{IATA: "AT7", Model: "ATR 72", BodyType: BodyTypeNarrow, SeatPitchInch: 29, Aliases: []string{"ATR 72-600", "ATR72"}},
+	}
+)
+
+// Airlines returns every airline in the catalog. The slice is a copy.
+func Airlines() []Airline {
+	return slices.Clone(airlines)
+}
+
+// AircraftTypes returns every aircraft type in the catalog. The slice and
+// the aliases are copies.
+func AircraftTypes() []Aircraft {
+	types := slices.Clone(aircraft)
+	for i := range types {
+		types[i].Aliases = slices.Clone(types[i].Aliases)
+	}
+	return types
+}
+
+func AirlineByIATA(code string) (Airline, bool) {
+	for _, a := range airlines {
+		if strings.EqualFold(a.IATA, code) {
+			return a, true
+		}
+	}
+	return Airline{}, false
+}
+
+func AircraftByIATA(code string) (Aircraft, bool) {
+	for _, a := range aircraft {
+		if strings.EqualFold(a.IATA, code) {
+			return a, true
+		}
+	}
+	return Aircraft{}, false
+}
+
+// AircraftByModel looks up an aircraft type by the model name a provider
+// reports, e.g. "Boeing 737-800" or "A320".
+func AircraftByModel(model string) (Aircraft, bool) {
+	model = strings.TrimSpace(model)
+	for _, a := range aircraft {
+		if strings.EqualFold(a.Model, model) {
+			return a, true
+		}
+		for _, alias := range a.Aliases {
+			if strings.EqualFold(alias, model) {
+				return a, true
+			}
+		}
+	}
+	return Aircraft{}, false
+}
+
+// AirlineInfo builds the airline of a flight from the catalog, falling back
+// to the provider's name for airlines the catalog does not know.
+func AirlineInfo(iata, providerName string) domain.AirlineInfo {
+	a, ok := AirlineByIATA(iata)
+	if !ok {
+		return domain.AirlineInfo{Name: providerName, Code: iata}
+	}
+	return domain.AirlineInfo{
+		Name:     a.Name,
+		Code:     a.IATA,
+		ICAO:     a.ICAO,
+		LogoKey:  a.LogoKey,
+		Alliance: a.Alliance,
+		LowCost:  a.LowCost,
+	}
+}
+
+// AircraftInfo builds the aircraft of a flight from the provider's model
+// name. When the provider does not report one, the airline's default
+// aircraft is returned flagged as assumed. It returns nil if neither is
+// known.
+func AircraftInfo(model, airlineIATA string) *domain.AircraftInfo {
+	if model == "" {
+		airline, ok := AirlineByIATA(airlineIATA)
+		if !ok {
+			return nil
+		}
+		a, ok := AircraftByIATA(airline.DefaultAircraft)
+		if !ok {
+			return nil
+		}
+		info := a.toDomain()
+		info.Assumed = true
+		return info
+	}
+
+	a, ok := AircraftByModel(model)
+	if !ok {
+		return &domain.AircraftInfo{Model: model}
+	}
+	return a.toDomain()
+}
+
+func (a Aircraft) toDomain() *domain.AircraftInfo {
+	return &domain.AircraftInfo{
+		Model:         a.Model,
+		Code:          a.IATA,
+		BodyType:      string(a.BodyType),
+		SeatPitchInch: a.SeatPitchInch,
+	}
+}
@@ -0,0 +1,55 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAircraftInfo(t *testing.T) {
+	tests := []struct {
+		name         string
+		model        string
+		airline      string
+		expectedCode string
+		expectedBody string
+		assumed      bool
+	}{
+		{name: "ExactModel", model: "Boeing 737-800", airline: "GA", expectedCode: "738", expectedBody: "narrow_body"},
+		{name: "Alias", model: "A330-300", airline: "GA", expectedCode: "333", expectedBody: "wide_body"},
+		{name: "CaseInsensitive", model: "airbus a320", airline: "ID", expectedCode: "320", expectedBody: "narrow_body"},
+		{name: "AirlineDefault", model: "", airline: "QZ", expectedCode: "320", expectedBody: "narrow_body", assumed: true},
+		{name: "UnknownModelKept", model: "Concorde", airline: "GA", expectedCode: "", expectedBody: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := AircraftInfo(tt.model, tt.airline)
+			assert.NotNil(t, info)
+			assert.Equal(t, tt.expectedCode, info.Code)
+			assert.Equal(t, tt.expectedBody, info.BodyType)
+			assert.Equal(t, tt.assumed, info.Assumed)
+		})
+	}
+
+	assert.Nil(t, AircraftInfo("", "XX"))
+}
+
+func TestCatalog_ListsAreCopies(t *testing.T) {
+	Airlines()[0].Name = "Changed"
+	AircraftTypes()[0].Model = "Changed"
+
+	assert.NotEqual(t, "Changed", Airlines()[0].Name)
+	assert.NotEqual(t, "Changed", AircraftTypes()[0].Model)
+}
+
+func TestAirlineInfo(t *testing.T) {
+	info := AirlineInfo("QZ", "Indonesia AirAsia")
+	assert.Equal(t, "AirAsia", info.Name)
+	assert.Equal(t, "AWQ", info.ICAO)
+	assert.True(t, info.LowCost)
+
+	info = AirlineInfo("XX", "Unknown Air")
+	assert.Equal(t, "Unknown Air", info.Name)
+	assert.Equal(t, "XX", info.Code)
+}
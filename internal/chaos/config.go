@@ -0,0 +1,105 @@
+package chaos
+
+import (
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/errors"
+)
+
+// LatencyDistribution shapes the simulated response time between
+// LatencyMinMs and LatencyMaxMs.
+type LatencyDistribution string
+
+const (
+	// LatencyUniform spreads latency evenly between min and max.
+	LatencyUniform LatencyDistribution = "uniform"
+	// LatencyNormal centers latency between min and max, clamped to the range.
+	LatencyNormal LatencyDistribution = "normal"
+	// LatencyExponential starts at min with a long tail that may exceed max.
+	LatencyExponential LatencyDistribution = "exponential"
+)
+
+// ErrorType selects which provider error an injected failure returns. The
+// values match the ErrCode of the provider errors.
+type ErrorType string
+
+const (
+	ErrorTypeInternal  ErrorType = "internal_error"
+	ErrorTypeRateLimit ErrorType = "rate_limit_exceeded"
+	ErrorTypeNotFound  ErrorType = "not_found"
+)
+
+// ProviderConfig is the fault injection of a single provider. Rates are
+// probabilities between 0 and 1 and are mutually exclusive per call.
+type ProviderConfig struct {
+	LatencyDistribution LatencyDistribution `mapstructure:"latency_distribution" json:"latency_distribution" envconfig:"LATENCY_DISTRIBUTION"`
+	LatencyMinMs        int                 `mapstructure:"latency_min_ms" json:"latency_min_ms" envconfig:"LATENCY_MIN_MS"`
+	LatencyMaxMs        int                 `mapstructure:"latency_max_ms" json:"latency_max_ms" envconfig:"LATENCY_MAX_MS"`
+	ErrorRate           float64             `mapstructure:"error_rate" json:"error_rate" envconfig:"ERROR_RATE"`
+	ErrorType           ErrorType           `mapstructure:"error_type" json:"error_type" envconfig:"ERROR_TYPE"`
+	TimeoutRate         float64             `mapstructure:"timeout_rate" json:"timeout_rate" envconfig:"TIMEOUT_RATE"`
+	MalformedRate       float64             `mapstructure:"malformed_rate" json:"malformed_rate" envconfig:"MALFORMED_RATE"`
+}
+
+// Validate checks the rates, latency range, distribution and error type.
+// Empty distribution and error type default to uniform and internal_error.
+func (pc ProviderConfig) Validate() error {
+	for _, r := range []float64{pc.ErrorRate, pc.TimeoutRate, pc.MalformedRate} {
+		if r < 0 || r > 1 {
+			return errors.ErrChaosInvalidRate
+		}
+	}
+	if pc.ErrorRate+pc.TimeoutRate+pc.MalformedRate > 1 {
+		return errors.ErrChaosInvalidRate
+	}
+	if pc.LatencyMinMs < 0 || pc.LatencyMinMs > pc.LatencyMaxMs {
+		return errors.ErrChaosInvalidLatency
+	}
+	switch pc.LatencyDistribution {
+	case "", LatencyUniform, LatencyNormal, LatencyExponential:
+	default:
+		return errors.ErrChaosInvalidDist
+	}
+	switch pc.ErrorType {
+	case "", ErrorTypeInternal, ErrorTypeRateLimit, ErrorTypeNotFound:
+	default:
+		return errors.ErrChaosInvalidErrType
+	}
+	return nil
+}
+
+// Config is the fault injection of every provider. Seed 0 seeds the RNG
+// from the current time; any other seed makes the injected faults
+// reproducible.
+type Config struct {
+	Enabled         bool           `mapstructure:"enabled" json:"enabled" envconfig:"ENABLED"`
+	Seed            int64          `mapstructure:"seed" json:"seed" envconfig:"SEED"`
+	AirAsia         ProviderConfig `mapstructure:"airasia" json:"airasia" envconfig:"AIRASIA"`
+	BatikAir        ProviderConfig `mapstructure:"batik" json:"batik" envconfig:"BATIK"`
+	GarudaIndonesia ProviderConfig `mapstructure:"garuda" json:"garuda" envconfig:"GARUDA"`
+	LionAir         ProviderConfig `mapstructure:"lion" json:"lion" envconfig:"LION"`
+}
+
+// Provider returns the configuration of a provider, nil for unknown keys.
+func (c *Config) Provider(key consts.ProviderKey) *ProviderConfig {
+	switch key {
+	case consts.ProviderKeyAirAsia:
+		return &c.AirAsia
+	case consts.ProviderKeyBatikAir:
+		return &c.BatikAir
+	case consts.ProviderKeyGarudaIndonesia:
+		return &c.GarudaIndonesia
+	case consts.ProviderKeyLionAir:
+		return &c.LionAir
+	}
+	return nil
+}
+
+// Validate checks the configuration of every provider.
+func (c Config) Validate() error {
+	for _, key := range consts.ProviderKeys {
+		if err := c.Provider(key).Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package chaos
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/errors"
+	"github.com/azcov/bookcabin_test/pkg/randz"
+)
+
+// Fault is the failure injected into a single provider call.
+type Fault string
+
+const (
+	FaultNone      Fault = ""
+	FaultError     Fault = "error"
+	FaultTimeout   Fault = "timeout"
+	FaultMalformed Fault = "malformed"
+)
+
+// Plan is what the injector decided for a single provider call. ErrorType
+// is set for FaultError only.
+type Plan struct {
+	Latency   time.Duration
+	Fault     Fault
+	ErrorType ErrorType
+}
+
+// Injector decides latency and faults for provider calls. Its configuration
+// can be changed at runtime. Each provider draws from its own RNG derived
+// from the seed, so concurrent fan-out does not change a provider's sequence.
+type Injector struct {
+	mu      sync.RWMutex
+	cfg     Config
+	newRand func(seed int64) randz.Rand
+	rngs    map[consts.ProviderKey]*providerRand
+}
+
+// providerRand serializes a provider's draws so each Plan is drawn as a unit.
+type providerRand struct {
+	mu  sync.Mutex
+	rnd randz.Rand
+}
+
+// NewInjector returns an Injector using cfg, which must be valid. newRand
+// builds the RNG of each provider from its seed; nil uses randz.New.
+func NewInjector(cfg Config, newRand func(seed int64) randz.Rand) *Injector {
+	if newRand == nil {
+		newRand = randz.New
+	}
+	i := &Injector{newRand: newRand}
+	i.apply(cfg)
+	return i
+}
+
+// Config returns the current configuration.
+func (i *Injector) Config() Config {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.cfg
+}
+
+// SetConfig validates and replaces the whole configuration, reseeding the
+// RNGs.
+func (i *Injector) SetConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.apply(cfg)
+	return nil
+}
+
+// SetProvider validates and replaces the configuration of one provider.
+func (i *Injector) SetProvider(key consts.ProviderKey, pc ProviderConfig) error {
+	if err := pc.Validate(); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	target := i.cfg.Provider(key)
+	if target == nil {
+		return errors.ErrChaosUnknownProvider
+	}
+	*target = pc
+	return nil
+}
+
+// apply replaces the configuration and reseeds. Callers hold mu.
+func (i *Injector) apply(cfg Config) {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	i.cfg = cfg
+	i.rngs = make(map[consts.ProviderKey]*providerRand, len(consts.ProviderKeys))
+	for _, key := range consts.ProviderKeys {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		i.rngs[key] = &providerRand{rnd: i.newRand(seed ^ int64(h.Sum64()))}
+	}
+}
+
+// Plan decides the latency and fault of the next call to a provider. A
+// disabled injector or an unknown provider gets an empty plan.
+func (i *Injector) Plan(key consts.ProviderKey) Plan {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	pc := i.cfg.Provider(key)
+	if !i.cfg.Enabled || pc == nil {
+		return Plan{}
+	}
+	pr := i.rngs[key]
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	plan := Plan{Latency: latency(pr.rnd, *pc)}
+	switch u := pr.rnd.Float64(); {
+	case u < pc.ErrorRate:
+		plan.Fault = FaultError
+		plan.ErrorType = pc.ErrorType
+		if plan.ErrorType == "" {
+			plan.ErrorType = ErrorTypeInternal
+		}
+	case u < pc.ErrorRate+pc.TimeoutRate:
+		plan.Fault = FaultTimeout
+	case u < pc.ErrorRate+pc.TimeoutRate+pc.MalformedRate:
+		plan.Fault = FaultMalformed
+	}
+	return plan
+}
+
+func latency(rnd randz.Rand, pc ProviderConfig) time.Duration {
+	lo, hi := float64(pc.LatencyMinMs), float64(pc.LatencyMaxMs)
+	var ms float64
+	switch pc.LatencyDistribution {
+	case LatencyNormal:
+		// 99.7% of the samples fall within min and max before clamping
+		ms = (lo+hi)/2 + rnd.NormFloat64()*(hi-lo)/6
+		ms = math.Min(math.Max(ms, lo), hi)
+	case LatencyExponential:
+		// The mean sits at a third of the range, leaving a tail past max
+		ms = lo + rnd.ExpFloat64()*(hi-lo)/3
+	default:
+		ms = lo + rnd.Float64()*(hi-lo)
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// Corrupt truncates a payload so that it no longer decodes.
+func Corrupt(data []byte) []byte {
+	return data[:len(data)/2]
+}
+
+type faultKey struct{}
+
+// WithFault attaches a fault to ctx for code deeper in the call, such as a
+// payload decoder, to act on.
+func WithFault(ctx context.Context, fault Fault) context.Context {
+	return context.WithValue(ctx, faultKey{}, fault)
+}
+
+// FaultFromContext returns the fault attached to ctx, or FaultNone.
+func FaultFromContext(ctx context.Context) Fault {
+	fault, _ := ctx.Value(faultKey{}).(Fault)
+	return fault
+}
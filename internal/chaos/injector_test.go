@@ -0,0 +1,127 @@
+package chaos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjector_PlanIsDeterministicWithSeed(t *testing.T) {
+	cfg := Config{
+		Enabled: true,
+		Seed:    42,
+		AirAsia: ProviderConfig{LatencyMinMs: 50, LatencyMaxMs: 150, ErrorRate: 0.3, TimeoutRate: 0.2, MalformedRate: 0.1},
+		LionAir: ProviderConfig{LatencyMinMs: 100, LatencyMaxMs: 200, ErrorRate: 0.5},
+	}
+	a := NewInjector(cfg, nil)
+	b := NewInjector(cfg, nil)
+
+	// Interleaving other providers does not change a provider's sequence
+	for range 50 {
+		b.Plan(consts.ProviderKeyLionAir)
+		assert.Equal(t, a.Plan(consts.ProviderKeyAirAsia), b.Plan(consts.ProviderKeyAirAsia))
+	}
+}
+
+func TestInjector_Plan(t *testing.T) {
+	tests := []struct {
+		name          string
+		enabled       bool
+		pc            ProviderConfig
+		expectedFault Fault
+		expectedType  ErrorType
+	}{
+		{name: "Disabled", enabled: false, pc: ProviderConfig{ErrorRate: 1}, expectedFault: FaultNone},
+		{name: "No faults", enabled: true, pc: ProviderConfig{}, expectedFault: FaultNone},
+		{name: "Error", enabled: true, pc: ProviderConfig{ErrorRate: 1, ErrorType: ErrorTypeNotFound}, expectedFault: FaultError, expectedType: ErrorTypeNotFound},
+		{name: "Error defaults to internal", enabled: true, pc: ProviderConfig{ErrorRate: 1}, expectedFault: FaultError, expectedType: ErrorTypeInternal},
+		{name: "Timeout", enabled: true, pc: ProviderConfig{TimeoutRate: 1}, expectedFault: FaultTimeout},
+		{name: "Malformed", enabled: true, pc: ProviderConfig{MalformedRate: 1}, expectedFault: FaultMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector := NewInjector(Config{Enabled: tt.enabled, Seed: 1, GarudaIndonesia: tt.pc}, nil)
+
+			plan := injector.Plan(consts.ProviderKeyGarudaIndonesia)
+			assert.Equal(t, tt.expectedFault, plan.Fault)
+			assert.Equal(t, tt.expectedType, plan.ErrorType)
+		})
+	}
+}
+
+func TestInjector_PlanLatency(t *testing.T) {
+	tests := []struct {
+		name string
+		dist LatencyDistribution
+		max  time.Duration
+	}{
+		{name: "Uniform", dist: LatencyUniform, max: 150 * time.Millisecond},
+		{name: "Normal", dist: LatencyNormal, max: 150 * time.Millisecond},
+		// The exponential tail is unbounded, only the minimum holds
+		{name: "Exponential", dist: LatencyExponential},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector := NewInjector(Config{
+				Enabled:  true,
+				Seed:     7,
+				BatikAir: ProviderConfig{LatencyDistribution: tt.dist, LatencyMinMs: 50, LatencyMaxMs: 150},
+			}, nil)
+
+			for range 200 {
+				latency := injector.Plan(consts.ProviderKeyBatikAir).Latency
+				assert.GreaterOrEqual(t, latency, 50*time.Millisecond)
+				if tt.max > 0 {
+					assert.LessOrEqual(t, latency, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestInjector_SetProvider(t *testing.T) {
+	injector := NewInjector(Config{Enabled: true}, nil)
+
+	err := injector.SetProvider(consts.ProviderKeyLionAir, ProviderConfig{ErrorRate: 0.5, LatencyMaxMs: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, injector.Config().LionAir.ErrorRate)
+
+	err = injector.SetProvider("unknown", ProviderConfig{})
+	assert.Equal(t, errors.ErrChaosUnknownProvider, err)
+
+	err = injector.SetProvider(consts.ProviderKeyLionAir, ProviderConfig{ErrorRate: 2})
+	assert.Equal(t, errors.ErrChaosInvalidRate, err)
+	assert.Equal(t, 0.5, injector.Config().LionAir.ErrorRate)
+}
+
+func TestProviderConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		pc       ProviderConfig
+		expected error
+	}{
+		{name: "Valid", pc: ProviderConfig{LatencyDistribution: LatencyNormal, LatencyMinMs: 10, LatencyMaxMs: 20, ErrorRate: 0.5, TimeoutRate: 0.5}},
+		{name: "Negative rate", pc: ProviderConfig{ErrorRate: -0.1}, expected: errors.ErrChaosInvalidRate},
+		{name: "Rates above one", pc: ProviderConfig{ErrorRate: 0.6, MalformedRate: 0.6}, expected: errors.ErrChaosInvalidRate},
+		{name: "Min above max", pc: ProviderConfig{LatencyMinMs: 20, LatencyMaxMs: 10}, expected: errors.ErrChaosInvalidLatency},
+		{name: "Unknown distribution", pc: ProviderConfig{LatencyDistribution: "pareto"}, expected: errors.ErrChaosInvalidDist},
+		{name: "Unknown error type", pc: ProviderConfig{ErrorType: "teapot"}, expected: errors.ErrChaosInvalidErrType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.pc.Validate())
+		})
+	}
+}
+
+func TestFaultFromContext(t *testing.T) {
+	assert.Equal(t, FaultNone, FaultFromContext(context.Background()))
+	assert.Equal(t, FaultMalformed, FaultFromContext(WithFault(context.Background(), FaultMalformed)))
+}
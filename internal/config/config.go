@@ -3,16 +3,98 @@ package config
 import (
 	"sync"
 
+	"github.com/azcov/bookcabin_test/internal/chaos"
+	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/pkg/cache"
 	"github.com/azcov/bookcabin_test/pkg/httpz"
 	"github.com/azcov/bookcabin_test/pkg/logger"
+	"github.com/azcov/bookcabin_test/pkg/tracing"
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
-	Http   httpz.HttpConfig    `mapstructure:"http" json:"http" env:"HTTP"`
-	Cache  cache.CacheConfig   `mapstructure:"cache" json:"cache" env:"CACHE"`
-	Logger logger.LoggerConfig `mapstructure:"logger" json:"logger" env:"LOGGER"`
+	Http        httpz.HttpConfig      `mapstructure:"http" json:"http" env:"HTTP"`
+	Cache       cache.CacheConfig     `mapstructure:"cache" json:"cache" env:"CACHE"`
+	CachePolicy CachePolicyConfig     `mapstructure:"cache_policy" json:"cache_policy" env:"CACHE_POLICY" envconfig:"CACHE_POLICY"`
+	Logger      logger.LoggerConfig   `mapstructure:"logger" json:"logger" env:"LOGGER"`
+	Chaos       chaos.Config          `mapstructure:"chaos" json:"chaos" env:"CHAOS"`
+	Hedge       HedgeConfig           `mapstructure:"hedge" json:"hedge" env:"HEDGE"`
+	Search      SearchConfig          `mapstructure:"search" json:"search" env:"SEARCH"`
+	Warmup      WarmupConfig          `mapstructure:"warmup" json:"warmup" env:"WARMUP"`
+	Tracing     tracing.TracingConfig `mapstructure:"tracing" json:"tracing" env:"TRACING"`
+}
+
+// HedgeConfig controls hedged requests: when a provider has not answered
+// within the Percentile of its recent latencies, a second request is sent and
+// whichever succeeds first is used.
+type HedgeConfig struct {
+	Enabled    bool    `mapstructure:"enabled" json:"enabled" envconfig:"ENABLED"`
+	Percentile float64 `mapstructure:"percentile" json:"percentile" envconfig:"PERCENTILE"`
+	// MinSamples is the number of latencies needed before the percentile is
+	// trusted; until then DelayMs is used.
+	MinSamples int `mapstructure:"min_samples" json:"min_samples" envconfig:"MIN_SAMPLES"`
+	DelayMs    int `mapstructure:"delay_ms" json:"delay_ms" envconfig:"DELAY_MS"`
+	// WindowSize is the number of recent latencies kept per provider.
+	WindowSize int `mapstructure:"window_size" json:"window_size" envconfig:"WINDOW_SIZE"`
+}
+
+// WarmupConfig schedules searches for popular routes so that their results
+// are cached before clients ask for them.
+type WarmupConfig struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled" envconfig:"ENABLED"`
+	// Routes are searched from today for Days days, e.g. "CGK-DPS,CGK-SUB",
+	// every IntervalMinutes.
+	Routes          []string `mapstructure:"routes" json:"routes" envconfig:"ROUTES"`
+	Days            int      `mapstructure:"days" json:"days" envconfig:"DAYS"`
+	IntervalMinutes int      `mapstructure:"interval_minutes" json:"interval_minutes" envconfig:"INTERVAL_MINUTES"`
+	// MinProviderQuota is the rate limit quota every provider must have left
+	// for a warm-up search to be sent; searches without it are skipped until
+	// the next run, leaving the quota to client searches.
+	MinProviderQuota int `mapstructure:"min_provider_quota" json:"min_provider_quota" envconfig:"MIN_PROVIDER_QUOTA"`
+}
+
+// CachePolicyConfig decides how long search results are cached, starting
+// from the cache expiration.
+type CachePolicyConfig struct {
+	// RouteTTLMinutes overrides the cache expiration per route, e.g.
+	// "CGK-DPS:2,CGK-SUB:15".
+	RouteTTLMinutes map[string]int `mapstructure:"route_ttl_minutes" json:"route_ttl_minutes" envconfig:"ROUTE_TTL_MINUTES"`
+	// PerDayPercent grows the TTL by this percentage for every day until
+	// departure, up to MaxTTLMinutes, as fares far out change less often.
+	PerDayPercent int `mapstructure:"per_day_percent" json:"per_day_percent" envconfig:"PER_DAY_PERCENT"`
+	MaxTTLMinutes int `mapstructure:"max_ttl_minutes" json:"max_ttl_minutes" envconfig:"MAX_TTL_MINUTES"`
+	// EmptyTTLSeconds caps how long a search without flights is cached; 0
+	// does not cache such searches.
+	EmptyTTLSeconds int `mapstructure:"empty_ttl_seconds" json:"empty_ttl_seconds" envconfig:"EMPTY_TTL_SECONDS"`
+
+	// RawEnabled caches the raw provider results below the filtered and
+	// sorted results, so refining a search does not query the providers
+	// again. RawPerProvider keys them per provider, each with its own TTL
+	// from ProviderTTLSeconds, e.g. "airasia:60,garuda:600".
+	RawEnabled         bool           `mapstructure:"raw_enabled" json:"raw_enabled" envconfig:"RAW_ENABLED"`
+	RawPerProvider     bool           `mapstructure:"raw_per_provider" json:"raw_per_provider" envconfig:"RAW_PER_PROVIDER"`
+	ProviderTTLSeconds map[string]int `mapstructure:"provider_ttl_seconds" json:"provider_ttl_seconds" envconfig:"PROVIDER_TTL_SECONDS"`
+
+	// SoftTTLPercent turns cached results stale after this percentage of
+	// their TTL. Stale results are still served, flagged as stale, while
+	// they are refreshed in the background; 0 disables it.
+	SoftTTLPercent int `mapstructure:"soft_ttl_percent" json:"soft_ttl_percent" envconfig:"SOFT_TTL_PERCENT"`
+}
+
+// SearchConfig holds the defaults for search modes and the policy for
+// caching degraded results, i.e. results missing some providers.
+type SearchConfig struct {
+	DefaultMode consts.SearchMode `mapstructure:"default_mode" json:"default_mode" envconfig:"DEFAULT_MODE"`
+	// FastWaitMs is the wait of a fast search that does not set maxWaitMs.
+	FastWaitMs    int  `mapstructure:"fast_wait_ms" json:"fast_wait_ms" envconfig:"FAST_WAIT_MS"`
+	CacheDegraded bool `mapstructure:"cache_degraded" json:"cache_degraded" envconfig:"CACHE_DEGRADED"`
+	// DegradedCacheTTLSeconds caps how long a degraded result is cached.
+	DegradedCacheTTLSeconds int `mapstructure:"degraded_cache_ttl_seconds" json:"degraded_cache_ttl_seconds" envconfig:"DEGRADED_CACHE_TTL_SECONDS"`
+	// RefreshWorkers re-query the failed providers of cached degraded results
+	// in the background; 0 disables the refresh. At most RefreshQueueSize
+	// refreshes wait for a worker, further ones are dropped.
+	RefreshWorkers   int `mapstructure:"refresh_workers" json:"refresh_workers" envconfig:"REFRESH_WORKERS"`
+	RefreshQueueSize int `mapstructure:"refresh_queue_size" json:"refresh_queue_size" envconfig:"REFRESH_QUEUE_SIZE"`
 }
 
 func NewConfig() *Config {
@@ -24,11 +106,80 @@ func NewConfig() *Config {
 			Enabled:               true,
 			ExpirationMinute:      5,
 			CleanupIntervalMinute: 10,
+			Driver:                cache.DriverMemory,
+			Redis: cache.RedisConfig{
+				Addr:      "localhost:6379",
+				KeyPrefix: "bookcabin:",
+				TimeoutMs: 500,
+			},
+		},
+		CachePolicy: CachePolicyConfig{
+			PerDayPercent:   10,
+			MaxTTLMinutes:   60,
+			EmptyTTLSeconds: 30,
+			RawEnabled:      true,
+			RawPerProvider:  true,
+			SoftTTLPercent:  50,
 		},
 		Logger: logger.LoggerConfig{
 			Level:       "info",
 			Environment: "development",
 		},
+		Hedge: HedgeConfig{
+			Enabled:    true,
+			Percentile: 95,
+			MinSamples: 20,
+			DelayMs:    300,
+			WindowSize: 100,
+		},
+		Search: SearchConfig{
+			DefaultMode:             consts.SearchModeWaitAll,
+			FastWaitMs:              800,
+			CacheDegraded:           true,
+			DegradedCacheTTLSeconds: 10,
+			RefreshWorkers:          4,
+			RefreshQueueSize:        100,
+		},
+		Warmup: WarmupConfig{
+			Enabled:          false,
+			Routes:           []string{"CGK-DPS"},
+			Days:             14,
+			IntervalMinutes:  5,
+			MinProviderQuota: 50,
+		},
+		Tracing: tracing.TracingConfig{
+			Enabled:      false,
+			ServiceName:  "bookcabin",
+			Exporter:     tracing.ExporterStdout,
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+			SampleRatio:  1,
+		},
+		Chaos: chaos.Config{
+			Enabled: true,
+			AirAsia: chaos.ProviderConfig{
+				LatencyDistribution: chaos.LatencyUniform,
+				LatencyMinMs:        50,
+				LatencyMaxMs:        150,
+				ErrorRate:           0.10,
+				ErrorType:           chaos.ErrorTypeInternal,
+			},
+			BatikAir: chaos.ProviderConfig{
+				LatencyDistribution: chaos.LatencyUniform,
+				LatencyMinMs:        200,
+				LatencyMaxMs:        400,
+			},
+			GarudaIndonesia: chaos.ProviderConfig{
+				LatencyDistribution: chaos.LatencyUniform,
+				LatencyMinMs:        50,
+				LatencyMaxMs:        100,
+			},
+			LionAir: chaos.ProviderConfig{
+				LatencyDistribution: chaos.LatencyUniform,
+				LatencyMinMs:        100,
+				LatencyMaxMs:        200,
+			},
+		},
 	}
 }
 
@@ -43,6 +194,10 @@ func LoadConfig(c *Config) error {
 		if err != nil {
 			logger.Fatal("Failed to load config: ", "err", err.Error())
 		}
+		err = c.Chaos.Validate()
+		if err != nil {
+			logger.Fatal("Invalid chaos config: ", "err", err.Error())
+		}
 	})
 	return err
 }
@@ -4,12 +4,24 @@ const (
 	AirportCGK = "CGK"
 	AirportDPS = "DPS"
 	AirportSOC = "SOC"
+	AirportSUB = "SUB"
+	AirportUPG = "UPG"
 )
 
 var (
+	AirportCodeToTimezone = map[string]string{
+		AirportCGK: "Asia/Jakarta",
+		AirportDPS: "Asia/Makassar",
+		AirportSOC: "Asia/Jakarta",
+		AirportSUB: "Asia/Jakarta",
+		AirportUPG: "Asia/Makassar",
+	}
+
 	AirportCodeToCity = map[string]string{
 		AirportCGK: CityJakarta,
 		AirportDPS: CityDenpasar,
 		AirportSOC: CitySolo,
+		AirportSUB: CitySurabaya,
+		AirportUPG: CityMakassar,
 	}
 )
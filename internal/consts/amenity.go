@@ -0,0 +1,27 @@
+package consts
+
+type AmenityType string
+
+const (
+	AmenityTypeWifi          AmenityType = "wifi"
+	AmenityTypeMeal          AmenityType = "meal"
+	AmenityTypeSnack         AmenityType = "snack"
+	AmenityTypeBeverage      AmenityType = "beverage"
+	AmenityTypePower         AmenityType = "power"
+	AmenityTypeEntertainment AmenityType = "entertainment"
+	AmenityTypeExtraLegroom  AmenityType = "extra_legroom"
+	// AmenityTypeOther is used for provider amenities outside the taxonomy.
+	AmenityTypeOther AmenityType = "other"
+)
+
+var (
+	AmenityTypeToDescription = map[AmenityType]string{
+		AmenityTypeWifi:          "WiFi available",
+		AmenityTypeMeal:          "Meal included",
+		AmenityTypeSnack:         "Snack included",
+		AmenityTypeBeverage:      "Beverage included",
+		AmenityTypePower:         "In-seat power",
+		AmenityTypeEntertainment: "In-flight entertainment",
+		AmenityTypeExtraLegroom:  "Extra legroom",
+	}
+)
@@ -0,0 +1,60 @@
+package consts
+
+import "strings"
+
+type CabinClass string
+
+const (
+	CabinClassEconomy        CabinClass = "economy"
+	CabinClassPremiumEconomy CabinClass = "premium_economy"
+	CabinClassBusiness       CabinClass = "business"
+	CabinClassFirst          CabinClass = "first"
+)
+
+var (
+	// BookingClassToCabinClass is the conventional mapping of reservation
+	// booking designator (RBD) letters to cabins. Providers whose fare
+	// structure differs override individual letters.
+	BookingClassToCabinClass = map[string]CabinClass{
+		"F": CabinClassFirst, "A": CabinClassFirst, "P": CabinClassFirst,
+		"J": CabinClassBusiness, "C": CabinClassBusiness, "D": CabinClassBusiness, "I": CabinClassBusiness, "Z": CabinClassBusiness, "R": CabinClassBusiness,
+		"W": CabinClassPremiumEconomy, "E": CabinClassPremiumEconomy,
+		"Y": CabinClassEconomy, "B": CabinClassEconomy, "M": CabinClassEconomy, "H": CabinClassEconomy, "K": CabinClassEconomy,
+		"L": CabinClassEconomy, "Q": CabinClassEconomy, "T": CabinClassEconomy, "V": CabinClassEconomy, "X": CabinClassEconomy,
+		"N": CabinClassEconomy, "S": CabinClassEconomy, "G": CabinClassEconomy, "U": CabinClassEconomy, "O": CabinClassEconomy,
+	}
+)
+
+// ParseCabinClass normalizes a cabin name such as "Economy", "PREMIUM ECONOMY"
+// or "premium-economy".
+func ParseCabinClass(s string) (CabinClass, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer(" ", "_", "-", "_").Replace(s)
+	switch c := CabinClass(s); c {
+	case CabinClassEconomy, CabinClassPremiumEconomy, CabinClassBusiness, CabinClassFirst:
+		return c, true
+	}
+	return "", false
+}
+
+// CabinClassFromBookingClass resolves a booking class letter, checking the
+// provider's overrides before the conventional mapping.
+func CabinClassFromBookingClass(overrides map[string]CabinClass, rbd string) (CabinClass, bool) {
+	rbd = strings.ToUpper(strings.TrimSpace(rbd))
+	if c, ok := overrides[rbd]; ok {
+		return c, true
+	}
+	c, ok := BookingClassToCabinClass[rbd]
+	return c, ok
+}
+
+// ResolveCabinClass maps a provider's cabin field, which is either a cabin
+// name or a booking class letter, to a cabin. It returns "" when neither
+// matches.
+func ResolveCabinClass(overrides map[string]CabinClass, raw string) CabinClass {
+	if c, ok := ParseCabinClass(raw); ok {
+		return c
+	}
+	c, _ := CabinClassFromBookingClass(overrides, raw)
+	return c
+}
@@ -0,0 +1,34 @@
+package consts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveCabinClass(t *testing.T) {
+	noFirst := map[string]CabinClass{"F": CabinClassBusiness}
+
+	tests := []struct {
+		name      string
+		overrides map[string]CabinClass
+		raw       string
+		expected  CabinClass
+	}{
+		{name: "LowercaseName", raw: "economy", expected: CabinClassEconomy},
+		{name: "UppercaseName", raw: "ECONOMY", expected: CabinClassEconomy},
+		{name: "SpacedName", raw: "Premium Economy", expected: CabinClassPremiumEconomy},
+		{name: "EconomyRBD", raw: "Y", expected: CabinClassEconomy},
+		{name: "DiscountEconomyRBD", raw: "q", expected: CabinClassEconomy},
+		{name: "BusinessRBD", raw: "C", expected: CabinClassBusiness},
+		{name: "FirstRBD", raw: "F", expected: CabinClassFirst},
+		{name: "ProviderOverride", overrides: noFirst, raw: "F", expected: CabinClassBusiness},
+		{name: "Unknown", raw: "cargo", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ResolveCabinClass(tt.overrides, tt.raw))
+		})
+	}
+}
@@ -4,4 +4,6 @@ const (
 	CityJakarta  = "Jakarta"
 	CityDenpasar = "Denpasar"
 	CitySolo     = "Solo"
+	CitySurabaya = "Surabaya"
+	CityMakassar = "Makassar"
 )
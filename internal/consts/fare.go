@@ -0,0 +1,20 @@
+package consts
+
+import "strings"
+
+type FareBrand string
+
+const (
+	FareBrandLite  FareBrand = "lite"
+	FareBrandValue FareBrand = "value"
+	FareBrandFlex  FareBrand = "flex"
+)
+
+// ParseFareBrand normalizes a fare brand name such as "Lite" or " FLEX ".
+func ParseFareBrand(s string) (FareBrand, bool) {
+	switch b := FareBrand(strings.ToLower(strings.TrimSpace(s))); b {
+	case FareBrandLite, FareBrandValue, FareBrandFlex:
+		return b, true
+	}
+	return "", false
+}
@@ -0,0 +1,19 @@
+package consts
+
+type PassengerType string
+
+const (
+	PassengerTypeAdult  PassengerType = "adult"
+	PassengerTypeChild  PassengerType = "child"
+	PassengerTypeInfant PassengerType = "infant"
+)
+
+const (
+	// ChildFareRatio is the share of the adult base fare charged for a child (2-11 years).
+	ChildFareRatio = 0.75
+	// InfantFareRatio is the share of the adult base fare charged for a lap infant (under 2 years).
+	InfantFareRatio = 0.10
+)
+
+// MaxSeatedPassengers is the maximum number of adults and children in a single search.
+const MaxSeatedPassengers = 9
@@ -0,0 +1,19 @@
+package consts
+
+// ProviderKey identifies an airline provider in configuration, logs and
+// admin endpoints.
+type ProviderKey string
+
+const (
+	ProviderKeyAirAsia         ProviderKey = "airasia"
+	ProviderKeyBatikAir        ProviderKey = "batik"
+	ProviderKeyGarudaIndonesia ProviderKey = "garuda"
+	ProviderKeyLionAir         ProviderKey = "lion"
+)
+
+var ProviderKeys = []ProviderKey{
+	ProviderKeyAirAsia,
+	ProviderKeyBatikAir,
+	ProviderKeyGarudaIndonesia,
+	ProviderKeyLionAir,
+}
@@ -18,6 +18,17 @@ const (
 
 )
 
+// SearchMode selects how long a search waits for the providers.
+type SearchMode string
+
+const (
+	// SearchModeWaitAll waits for every provider up to the search deadline.
+	SearchModeWaitAll SearchMode = "wait_all"
+	// SearchModeFast returns whatever the providers answered within the
+	// requested wait.
+	SearchModeFast SearchMode = "fast"
+)
+
 type FilterKey string
 
 const (
@@ -32,4 +43,10 @@ const (
 	FilterKeyDepartureBefore FilterKey = "departure_before"
 	FilterKeyArrivalAfter    FilterKey = "arrival_after"
 	FilterKeyArrivalBefore   FilterKey = "arrival_before"
+
+	FilterKeyMaxLayoverDuration  FilterKey = "max_layover_duration"  // minutes, applied to every layover
+	FilterKeyAvoidLayoverAirport FilterKey = "avoid_layover_airport" // airport code or list of airport codes
+	FilterKeyRefundable          FilterKey = "refundable"
+	FilterKeyCheckedBaggage      FilterKey = "checked_baggage_included"
+	FilterKeyAmenities           FilterKey = "amenities" // amenity type or list of amenity types, all required
 )
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/errors"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+)
+
+// CacheEntryInfo describes a cached entry for the cache administration.
+type CacheEntryInfo struct {
+	Key       string     `json:"key"`
+	StoredAt  time.Time  `json:"stored_at"`
+	StaleAt   *time.Time `json:"stale_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	AgeMs     int64      `json:"age_ms"`
+	// TTLMs is the time left until the entry expires, -1 when it never does.
+	TTLMs int64 `json:"ttl_ms"`
+	Stale bool  `json:"stale"`
+	Value any   `json:"value"`
+}
+
+// CachePurgeRequest selects the cached entries to purge: those of a route,
+// those holding results of a provider, or those of a provider on a route.
+type CachePurgeRequest struct {
+	Origin      string             `form:"origin" json:"origin,omitempty"`
+	Destination string             `form:"destination" json:"destination,omitempty"`
+	Provider    consts.ProviderKey `form:"provider" json:"provider,omitempty"`
+}
+
+func (r *CachePurgeRequest) Validate() error {
+	if (r.Origin == "") != (r.Destination == "") {
+		return errors.ErrInvalidCachePurge
+	}
+	if r.Origin == "" && r.Provider == "" {
+		return errors.ErrInvalidCachePurge
+	}
+	if r.Provider != "" && !slices.Contains(consts.ProviderKeys, r.Provider) {
+		return errors.ErrCacheUnknownProvider
+	}
+	return nil
+}
+
+type CachePurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// CacheStats reports the cache lookups and the warm-up of popular routes.
+type CacheStats struct {
+	cache.Stats
+	Warmup WarmupStats `json:"warmup"`
+}
+
+// WarmupStats counts the warm-up searches since start. Searches are cache
+// hits when the route was still cached, and skipped when the providers had
+// no rate limit quota to spare.
+type WarmupStats struct {
+	Runs      uint64     `json:"runs"`
+	Fetched   uint64     `json:"fetched"`
+	CacheHits uint64     `json:"cache_hits"`
+	Failed    uint64     `json:"failed"`
+	Skipped   uint64     `json:"skipped"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	LastRunMs int64      `json:"last_run_ms"`
+}
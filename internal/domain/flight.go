@@ -1,17 +1,59 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/util"
+)
 
 type AirlineInfo struct {
-	Name string `json:"name"`
-	Code string `json:"code"`
+	Name     string `json:"name"`
+	Code     string `json:"code"`
+	ICAO     string `json:"icao,omitempty"`
+	LogoKey  string `json:"logo_key,omitempty"`
+	Alliance string `json:"alliance,omitempty"`
+	LowCost  bool   `json:"low_cost"`
 }
 
+// AirportInfo is one end of a flight or segment. Datetime is in the
+// airport's local timezone, DatetimeUTC is the same instant in UTC.
 type AirportInfo struct {
-	Airport   string    `json:"airport"`
-	City      string    `json:"city"`
-	Datetime  time.Time `json:"datetime"`
-	Timestamp int64     `json:"timestamp"`
+	Airport     string    `json:"airport"`
+	City        string    `json:"city"`
+	Terminal    string    `json:"terminal,omitempty"`
+	Timezone    string    `json:"timezone,omitempty"`
+	Datetime    time.Time `json:"datetime,omitzero"`
+	DatetimeUTC time.Time `json:"datetime_utc,omitzero"`
+	Timestamp   int64     `json:"timestamp,omitempty"`
+}
+
+// NewAirportInfo normalizes a provider time to the airport's local timezone.
+// city falls back to the airport's known city when the provider omits it.
+func NewAirportInfo(airport, city, terminal string, t time.Time) AirportInfo {
+	if city == "" {
+		city = consts.AirportCodeToCity[airport]
+	}
+	a := AirportInfo{
+		Airport:  airport,
+		City:     city,
+		Terminal: terminal,
+		Timezone: consts.AirportCodeToTimezone[airport],
+	}
+	if !t.IsZero() {
+		a.Datetime = util.ToAirportLocal(t, airport)
+		a.DatetimeUTC = t.UTC()
+		a.Timestamp = t.Unix()
+	}
+	return a
+}
+
+// LocalDate returns the date of the departure or arrival at the airport.
+func (a AirportInfo) LocalDate() string {
+	return util.AirportLocalDate(a.Datetime, a.Airport)
 }
 
 type DurationInfo struct {
@@ -19,45 +61,315 @@ type DurationInfo struct {
 	Formatted    string `json:"formatted"`
 }
 
+// NewDurationInfo returns the elapsed time between two instants.
+func NewDurationInfo(departure, arrival time.Time) DurationInfo {
+	minutes := util.DurationMinutes(departure, arrival)
+	return DurationInfo{
+		TotalMinutes: minutes,
+		Formatted:    util.FormatDurationMinute(minutes),
+	}
+}
+
+// PriceInfo holds the per-adult fare of a flight, its breakdown when the
+// provider reports one, and the total for the searched passengers.
 type PriceInfo struct {
-	Amount   int    `json:"amount"`
-	Currency string `json:"currency"`
-	Display  string `json:"display"`
+	Amount         int             `json:"amount"`
+	Currency       string          `json:"currency"`
+	Display        string          `json:"display"`
+	BaseFare       int             `json:"base_fare,omitempty"`
+	Taxes          int             `json:"taxes,omitempty"`
+	Fees           int             `json:"fees,omitempty"`
+	PassengerFares []PassengerFare `json:"passenger_fares"`
+	Total          int             `json:"total"`
+	TotalDisplay   string          `json:"total_display"`
+}
+
+type PassengerFare struct {
+	PassengerType consts.PassengerType `json:"passenger_type"`
+	BaseFare      int                  `json:"base_fare,omitempty"`
+	Taxes         int                  `json:"taxes,omitempty"`
+	Fees          int                  `json:"fees,omitempty"`
+	Amount        int                  `json:"amount"`
+	Display       string               `json:"display"`
+	Count         int                  `json:"count"`
+	Subtotal      int                  `json:"subtotal"`
+}
+
+// NewPriceInfo builds a PriceInfo from a provider's adult fare. baseFare,
+// taxes and fees are left zero when the provider only reports a total.
+// Child and infant fares are derived from the adult base fare.
+func NewPriceInfo(currency string, amount, baseFare, taxes, fees int) PriceInfo {
+	p := PriceInfo{
+		Amount:   amount,
+		Currency: currency,
+		Display:  util.FormatMoney(amount, currency),
+		BaseFare: baseFare,
+		Taxes:    taxes,
+		Fees:     fees,
+	}
+
+	// Without a breakdown the whole amount is treated as base fare
+	base := baseFare
+	if base == 0 {
+		base = amount
+		taxes, fees = 0, 0
+	}
+	child := int(math.Round(float64(base) * consts.ChildFareRatio))
+	infant := int(math.Round(float64(base) * consts.InfantFareRatio))
+
+	p.PassengerFares = []PassengerFare{
+		p.newPassengerFare(consts.PassengerTypeAdult, baseFare, p.Taxes, p.Fees, amount),
+		p.newPassengerFare(consts.PassengerTypeChild, child, taxes, fees, child+taxes+fees),
+		p.newPassengerFare(consts.PassengerTypeInfant, infant, 0, 0, infant),
+	}
+	p.CalculateTotal(PassengerCount{Adults: 1})
+	return p
+}
+
+func (p *PriceInfo) newPassengerFare(pt consts.PassengerType, baseFare, taxes, fees, amount int) PassengerFare {
+	return PassengerFare{
+		PassengerType: pt,
+		BaseFare:      baseFare,
+		Taxes:         taxes,
+		Fees:          fees,
+		Amount:        amount,
+		Display:       util.FormatMoney(amount, p.Currency),
+	}
+}
+
+// CalculateTotal sets the per-type subtotals and the total price for the
+// given passenger mix.
+func (p *PriceInfo) CalculateTotal(passengers PassengerCount) {
+	p.Total = 0
+	for i := range p.PassengerFares {
+		pf := &p.PassengerFares[i]
+		switch pf.PassengerType {
+		case consts.PassengerTypeAdult:
+			pf.Count = passengers.Adults
+		case consts.PassengerTypeChild:
+			pf.Count = passengers.Children
+		case consts.PassengerTypeInfant:
+			pf.Count = passengers.Infants
+		}
+		pf.Subtotal = pf.Amount * pf.Count
+		p.Total += pf.Subtotal
+	}
+	p.TotalDisplay = util.FormatMoney(p.Total, p.Currency)
 }
 
 type BaggageInfo struct {
-	CarryOn string `json:"carry_on"`
-	Checked string `json:"checked"`
+	CarryOn BaggageAllowance `json:"carry_on"`
+	Checked BaggageAllowance `json:"checked"`
+}
+
+// BaggageAllowance is one kind of baggage allowance. Pieces and WeightKg are
+// zero when the provider does not state them, Fee is nil when the baggage is
+// included or the provider does not quote a price.
+type BaggageAllowance struct {
+	Pieces      int    `json:"pieces"`
+	WeightKg    int    `json:"weight_kg"`
+	Included    bool   `json:"included"`
+	Fee         *int   `json:"fee"`
+	Description string `json:"description"`
+}
+
+func NewBaggageAllowance(pieces, weightKg int, included bool, fee *int) BaggageAllowance {
+	b := BaggageAllowance{
+		Pieces:   pieces,
+		WeightKg: weightKg,
+		Included: included,
+		Fee:      fee,
+	}
+	b.Description = b.describe()
+	return b
+}
+
+// describe derives the human-readable text, e.g. "2 pieces, 20 kg included".
+// The fee is left to the Fee field, the allowance does not know its currency.
+func (b BaggageAllowance) describe() string {
+	if !b.Included {
+		return "Not included, additional fee"
+	}
+
+	var parts []string
+	switch {
+	case b.Pieces == 1:
+		parts = append(parts, "1 piece")
+	case b.Pieces > 1:
+		parts = append(parts, fmt.Sprintf("%d pieces", b.Pieces))
+	}
+	if b.WeightKg > 0 {
+		parts = append(parts, fmt.Sprintf("%d kg", b.WeightKg))
+	}
+	if len(parts) == 0 {
+		return "Included"
+	}
+	return strings.Join(parts, ", ") + " included"
 }
 
 type AircraftInfo struct {
-	Model string `json:"model"`
-	Code  string `json:"code"`
+	Model         string `json:"model"`
+	Code          string `json:"code"`
+	BodyType      string `json:"body_type,omitempty"`
+	SeatPitchInch int    `json:"seat_pitch_inch,omitempty"`
+	// Assumed is set when the provider did not report the aircraft and the
+	// airline's usual equipment is shown instead.
+	Assumed bool `json:"assumed"`
 }
 
 type AmenityInfo struct {
-	Type        string `json:"type"`
-	Description string `json:"description"`
+	Type        consts.AmenityType `json:"type"`
+	Description string             `json:"description"`
+}
+
+// NewAmenityInfo maps a provider amenity through the provider's table.
+// Amenities missing from the table become AmenityTypeOther and keep the
+// provider's text as description.
+func NewAmenityInfo(table map[string]consts.AmenityType, raw string) AmenityInfo {
+	t, ok := table[strings.ToLower(strings.TrimSpace(raw))]
+	if !ok {
+		return AmenityInfo{Type: consts.AmenityTypeOther, Description: raw}
+	}
+	return AmenityInfo{Type: t, Description: consts.AmenityTypeToDescription[t]}
+}
+
+// HasAmenity reports whether the flight offers the given amenity.
+func (f *FlightInfo) HasAmenity(t consts.AmenityType) bool {
+	for _, a := range f.Amenities {
+		if a.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// FareOffer is one fare brand sold for a flight. ChangeFee is nil when the
+// provider does not say what a change costs.
+type FareOffer struct {
+	Brand                 consts.FareBrand `json:"brand"`
+	FareBasis             string           `json:"fare_basis,omitempty"`
+	Price                 PriceInfo        `json:"price"`
+	Refundable            bool             `json:"refundable"`
+	ChangeFee             *int             `json:"change_fee"`
+	Baggage               BaggageInfo      `json:"baggage"`
+	SeatSelectionIncluded bool             `json:"seat_selection_included"`
+}
+
+// Segment is a single flown leg of an itinerary. Providers that only report
+// connection airports leave the intermediate times and leg durations zero.
+type Segment struct {
+	FlightNumber string        `json:"flight_number"`
+	Departure    AirportInfo   `json:"departure"`
+	Arrival      AirportInfo   `json:"arrival"`
+	Duration     DurationInfo  `json:"duration"`
+	Aircraft     *AircraftInfo `json:"aircraft"`
+}
+
+// Layover is the connection time spent at an airport between two segments.
+type Layover struct {
+	Airport        string       `json:"airport"`
+	City           string       `json:"city"`
+	Duration       DurationInfo `json:"duration"`
+	TerminalChange bool         `json:"terminal_change"`
+}
+
+func NewLayover(airport string, minutes int) Layover {
+	return Layover{
+		Airport: airport,
+		City:    consts.AirportCodeToCity[airport],
+		Duration: DurationInfo{
+			TotalMinutes: minutes,
+			Formatted:    util.FormatDurationMinute(minutes),
+		},
+	}
+}
+
+// NewSegmentsFromLayovers splits a through flight into one segment per leg
+// for providers that only report where the flight stops. Only the first
+// departure and the last arrival times are known.
+func NewSegmentsFromLayovers(flightNumber string, departure, arrival AirportInfo, duration DurationInfo, aircraft *AircraftInfo, layovers []Layover) []Segment {
+	if len(layovers) == 0 {
+		return []Segment{{
+			FlightNumber: flightNumber,
+			Departure:    departure,
+			Arrival:      arrival,
+			Duration:     duration,
+			Aircraft:     aircraft,
+		}}
+	}
+
+	segments := make([]Segment, 0, len(layovers)+1)
+	from := departure
+	for _, l := range layovers {
+		to := NewAirportInfo(l.Airport, l.City, "", time.Time{})
+		segments = append(segments, Segment{FlightNumber: flightNumber, Departure: from, Arrival: to, Aircraft: aircraft})
+		from = to
+	}
+	segments = append(segments, Segment{FlightNumber: flightNumber, Departure: from, Arrival: arrival, Aircraft: aircraft})
+	return segments
 }
 
 type FlightInfo struct {
-	ID             string        `json:"id"`
-	Provider       string        `json:"provider"`
-	Airline        AirlineInfo   `json:"airline"`
-	FlightNumber   string        `json:"flight_number"`
-	Departure      AirportInfo   `json:"departure"`
-	Arrival        AirportInfo   `json:"arrival"`
-	Duration       DurationInfo  `json:"duration"`
-	Stops          int           `json:"stops"`
-	Price          PriceInfo     `json:"price"`
-	AvailableSeats int           `json:"available_seats"`
-	CabinClass     string        `json:"cabin_class"`
-	Aircraft       *AircraftInfo `json:"aircraft"`
-	Amenities      []AmenityInfo `json:"amenities"`
-	Baggage        BaggageInfo   `json:"baggage"`
-	BestValueScore float64       `json:"best_value_score"`
+	ID             string            `json:"id"`
+	Provider       string            `json:"provider"`
+	Airline        AirlineInfo       `json:"airline"`
+	FlightNumber   string            `json:"flight_number"`
+	Departure      AirportInfo       `json:"departure"`
+	Arrival        AirportInfo       `json:"arrival"`
+	Duration       DurationInfo      `json:"duration"`
+	Stops          int               `json:"stops"`
+	Segments       []Segment         `json:"segments"`
+	Layovers       []Layover         `json:"layovers"`
+	Price          PriceInfo         `json:"price"`
+	AvailableSeats int               `json:"available_seats"`
+	CabinClass     consts.CabinClass `json:"cabin_class"`
+	Aircraft       *AircraftInfo     `json:"aircraft"`
+	Amenities      []AmenityInfo     `json:"amenities"`
+	Baggage        BaggageInfo       `json:"baggage"`
+	FareOffers     []FareOffer       `json:"fare_offers"`
+	SelectedFare   FareOffer         `json:"-"`
+	BestValueScore float64           `json:"best_value_score"`
 	// Internal fields not exposed in API
-	TotalTripDuration int64 `json:"-"`
+	TotalTripDuration int64              `json:"-"`
+	ProviderKey       consts.ProviderKey `json:"-"`
+}
+
+// SelectFareOffer makes the offer of the given brand the flight's headline
+// fare, so price and baggage filters and sorting operate on it. An empty
+// brand selects the cheapest offer. It returns false if the flight does not
+// sell the brand.
+func (f *FlightInfo) SelectFareOffer(brand consts.FareBrand) bool {
+	idx := -1
+	for i, o := range f.FareOffers {
+		if brand != "" {
+			if o.Brand == brand {
+				idx = i
+				break
+			}
+			continue
+		}
+		if idx == -1 || o.Price.Amount < f.FareOffers[idx].Price.Amount {
+			idx = i
+		}
+	}
+	if idx == -1 {
+		return false
+	}
+
+	f.SelectedFare = f.FareOffers[idx]
+	f.Price = f.SelectedFare.Price
+	f.Baggage = f.SelectedFare.Baggage
+	return true
+}
+
+// CalculateTotalPrice sets the passenger totals of the headline price and of
+// every fare offer.
+func (f *FlightInfo) CalculateTotalPrice(passengers PassengerCount) {
+	f.Price.CalculateTotal(passengers)
+	for i := range f.FareOffers {
+		f.FareOffers[i].Price.CalculateTotal(passengers)
+	}
+	f.SelectedFare.Price.CalculateTotal(passengers)
 }
 
 func (f *FlightInfo) CalculateBestValueScore() {
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPriceInfo(t *testing.T) {
+	tests := []struct {
+		name                    string
+		amount, base, tax, fees int
+		expected                []PassengerFare
+	}{
+		{
+			name:   "Breakdown",
+			amount: 1150000, base: 1000000, tax: 100000, fees: 50000,
+			expected: []PassengerFare{
+				{PassengerType: consts.PassengerTypeAdult, BaseFare: 1000000, Taxes: 100000, Fees: 50000, Amount: 1150000},
+				// 75% of the base fare plus the adult taxes and fees
+				{PassengerType: consts.PassengerTypeChild, BaseFare: 750000, Taxes: 100000, Fees: 50000, Amount: 900000},
+				// 10% of the base fare, no taxes or fees
+				{PassengerType: consts.PassengerTypeInfant, BaseFare: 100000, Amount: 100000},
+			},
+		},
+		{
+			name:   "Total only",
+			amount: 1000000,
+			expected: []PassengerFare{
+				{PassengerType: consts.PassengerTypeAdult, Amount: 1000000},
+				{PassengerType: consts.PassengerTypeChild, BaseFare: 750000, Amount: 750000},
+				{PassengerType: consts.PassengerTypeInfant, BaseFare: 100000, Amount: 100000},
+			},
+		},
+		{
+			name:   "Rounded ratios",
+			amount: 1001, base: 1001,
+			expected: []PassengerFare{
+				{PassengerType: consts.PassengerTypeAdult, BaseFare: 1001, Amount: 1001},
+				{PassengerType: consts.PassengerTypeChild, BaseFare: 751, Amount: 751},
+				{PassengerType: consts.PassengerTypeInfant, BaseFare: 100, Amount: 100},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPriceInfo("IDR", tt.amount, tt.base, tt.tax, tt.fees)
+
+			assert.Len(t, p.PassengerFares, len(tt.expected))
+			for i, expected := range tt.expected {
+				pf := p.PassengerFares[i]
+				assert.Equal(t, expected.PassengerType, pf.PassengerType)
+				assert.Equal(t, expected.BaseFare, pf.BaseFare)
+				assert.Equal(t, expected.Taxes, pf.Taxes)
+				assert.Equal(t, expected.Fees, pf.Fees)
+				assert.Equal(t, expected.Amount, pf.Amount)
+			}
+			// Priced for one adult until the passengers are known
+			assert.Equal(t, tt.amount, p.Total)
+		})
+	}
+}
+
+func TestPriceInfo_CalculateTotal(t *testing.T) {
+	tests := []struct {
+		name       string
+		passengers PassengerCount
+		subtotals  map[consts.PassengerType]int
+		total      int
+		display    string
+	}{
+		{
+			name:       "Adults only",
+			passengers: PassengerCount{Adults: 2},
+			subtotals: map[consts.PassengerType]int{
+				consts.PassengerTypeAdult:  2300000,
+				consts.PassengerTypeChild:  0,
+				consts.PassengerTypeInfant: 0,
+			},
+			total:   2300000,
+			display: "IDR 2.300.000",
+		},
+		{
+			name:       "Mixed",
+			passengers: PassengerCount{Adults: 2, Children: 1, Infants: 1},
+			subtotals: map[consts.PassengerType]int{
+				consts.PassengerTypeAdult:  2300000,
+				consts.PassengerTypeChild:  900000,
+				consts.PassengerTypeInfant: 100000,
+			},
+			total:   3300000,
+			display: "IDR 3.300.000",
+		},
+		{
+			name:       "Infants pay no taxes or fees",
+			passengers: PassengerCount{Adults: 1, Infants: 2},
+			subtotals: map[consts.PassengerType]int{
+				consts.PassengerTypeAdult:  1150000,
+				consts.PassengerTypeChild:  0,
+				consts.PassengerTypeInfant: 200000,
+			},
+			total:   1350000,
+			display: "IDR 1.350.000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPriceInfo("IDR", 1150000, 1000000, 100000, 50000)
+			p.CalculateTotal(tt.passengers)
+
+			for _, pf := range p.PassengerFares {
+				assert.Equal(t, tt.subtotals[pf.PassengerType], pf.Subtotal, pf.PassengerType)
+			}
+			assert.Equal(t, tt.total, p.Total)
+			assert.Equal(t, tt.display, p.TotalDisplay)
+		})
+	}
+
+	t.Run("Recalculate_ResetsTotal", func(t *testing.T) {
+		p := NewPriceInfo("IDR", 1150000, 1000000, 100000, 50000)
+		p.CalculateTotal(PassengerCount{Adults: 3, Children: 2})
+		p.CalculateTotal(PassengerCount{Adults: 1})
+
+		assert.Equal(t, 1150000, p.Total)
+	})
+}
+
+func TestNewBaggageAllowance(t *testing.T) {
+	fee := 150000
+	tests := []struct {
+		name     string
+		b        BaggageAllowance
+		expected string
+	}{
+		{name: "Pieces and weight", b: NewBaggageAllowance(2, 20, true, nil), expected: "2 pieces, 20 kg included"},
+		{name: "One piece", b: NewBaggageAllowance(1, 0, true, nil), expected: "1 piece included"},
+		{name: "Unspecified", b: NewBaggageAllowance(0, 0, true, nil), expected: "Included"},
+		{name: "Not included", b: NewBaggageAllowance(0, 20, false, nil), expected: "Not included, additional fee"},
+		// The fee has no currency to be described with
+		{name: "Not included with fee", b: NewBaggageAllowance(0, 20, false, &fee), expected: "Not included, additional fee"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.b.Description)
+		})
+	}
+}
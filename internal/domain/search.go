@@ -1,22 +1,106 @@
 package domain
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/errors"
 )
 
 // SearchRequest represents the input parameters for a flight search.
 type SearchRequest struct {
-	Origin        string         `json:"origin" binding:"required"`
-	Destination   string         `json:"destination" binding:"required"`
-	DepartureDate string         `json:"departureDate" binding:"required"`
-	ReturnDate    *string        `json:"returnDate"`
-	Passengers    int            `json:"passengers" binding:"required,gte=1"`
-	CabinClass    string         `json:"cabinClass" binding:"required"`
-	Filters       []SearchFilter `json:"filters,omitempty"`
-	Sort          SortOption     `json:"sort"`
+	Origin        string            `json:"origin" binding:"required"`
+	Destination   string            `json:"destination" binding:"required"`
+	DepartureDate string            `json:"departureDate" binding:"required"`
+	ReturnDate    *string           `json:"returnDate"`
+	Passengers    PassengerCount    `json:"passengers" binding:"required"`
+	CabinClass    consts.CabinClass `json:"cabinClass" binding:"required"`
+	FareBrand     consts.FareBrand  `json:"fareBrand,omitempty"` // empty selects the cheapest offer
+	Filters       []SearchFilter    `json:"filters,omitempty"`
+	Sort          SortOption        `json:"sort"`
+	// Mode is wait_all (default) or fast; a fast search returns what the
+	// providers answered within MaxWaitMs.
+	Mode      consts.SearchMode `json:"mode,omitempty"`
+	MaxWaitMs int               `json:"maxWaitMs,omitempty"`
+}
+
+// PassengerCount is the passenger mix of a search. Infants travel on an
+// adult's lap and do not take a seat.
+type PassengerCount struct {
+	Adults   int `json:"adults"`
+	Children int `json:"children"`
+	Infants  int `json:"infants"`
+}
+
+// UnmarshalJSON accepts either a passenger mix object or, for older clients,
+// a bare number which is read as that many adults.
+func (pc *PassengerCount) UnmarshalJSON(data []byte) error {
+	var adults int
+	if err := json.Unmarshal(data, &adults); err == nil {
+		*pc = PassengerCount{Adults: adults}
+		return nil
+	}
+
+	type passengerCount PassengerCount
+	var v passengerCount
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*pc = PassengerCount(v)
+	return nil
+}
+
+// Seated returns the number of passengers that need a seat.
+func (pc PassengerCount) Seated() int {
+	return pc.Adults + pc.Children
+}
+
+func (pc PassengerCount) Validate() error {
+	if pc.Adults < 0 || pc.Children < 0 || pc.Infants < 0 {
+		return errors.ErrPassengersNegative
+	}
+	if pc.Adults == 0 {
+		return errors.ErrPassengersAdultRequired
+	}
+	if pc.Infants > pc.Adults {
+		return errors.ErrPassengersInfantsExceedAdults
+	}
+	if pc.Seated() > consts.MaxSeatedPassengers {
+		return errors.ErrPassengersSeatedLimitExceeded
+	}
+	return nil
+}
+
+// Validate checks the business rules that binding tags cannot express and
+// normalizes the cabin class and fare brand to their canonical values.
+func (sr *SearchRequest) Validate() error {
+	cabin, ok := consts.ParseCabinClass(string(sr.CabinClass))
+	if !ok {
+		return errors.ErrInvalidCabinClass
+	}
+	sr.CabinClass = cabin
+
+	// An unknown brand would silently filter out every flight
+	if sr.FareBrand != "" {
+		brand, ok := consts.ParseFareBrand(string(sr.FareBrand))
+		if !ok {
+			return errors.ErrInvalidFareBrand
+		}
+		sr.FareBrand = brand
+	}
+
+	switch sr.Mode {
+	case "", consts.SearchModeWaitAll, consts.SearchModeFast:
+	default:
+		return errors.ErrInvalidSearchMode
+	}
+	if sr.MaxWaitMs < 0 {
+		return errors.ErrInvalidMaxWait
+	}
+
+	return sr.Passengers.Validate()
 }
 
 type SearchFilter struct {
@@ -25,7 +109,7 @@ type SearchFilter struct {
 }
 
 func (sr *SearchRequest) ToCacheKey() string {
-	key := fmt.Sprintf("search_flight:origin=%s;destination=%s;departureDate=%s;returnDate=%v;passengers=%d;cabinClass=%s;",
+	key := fmt.Sprintf("search_flight:origin=%s;destination=%s;departureDate=%s;returnDate=%v;adults=%d;children=%d;infants=%d;cabinClass=%s;",
 		sr.Origin,
 		sr.Destination,
 		sr.DepartureDate,
@@ -35,7 +119,9 @@ func (sr *SearchRequest) ToCacheKey() string {
 			}
 			return "nil"
 		}(),
-		sr.Passengers,
+		sr.Passengers.Adults,
+		sr.Passengers.Children,
+		sr.Passengers.Infants,
 		sr.CabinClass,
 	)
 	var filterKey strings.Builder
@@ -43,11 +129,37 @@ func (sr *SearchRequest) ToCacheKey() string {
 		fmt.Fprintf(&filterKey, "%s=%v,", f.Key, f.Value)
 	}
 	key += filterKey.String() + ";"
+	key += fmt.Sprintf("fareBrand=%s;", sr.FareBrand)
 	key += fmt.Sprintf("sort_key=%s;sort_order=%s", sr.Sort.Key, sr.Sort.Order)
 
 	return key
 }
 
+// ToProviderCacheKey returns the key of the raw provider results of the
+// search. It leaves out filters, fare brand and sort, which are applied on
+// top of the raw results. An empty provider keys the results of all
+// providers together.
+func (sr *SearchRequest) ToProviderCacheKey(provider consts.ProviderKey) string {
+	returnDate := "nil"
+	if sr.ReturnDate != nil {
+		returnDate = *sr.ReturnDate
+	}
+	if provider == "" {
+		provider = "all"
+	}
+	return fmt.Sprintf("provider_results:origin=%s;destination=%s;departureDate=%s;returnDate=%s;adults=%d;children=%d;infants=%d;cabinClass=%s;provider=%s",
+		sr.Origin,
+		sr.Destination,
+		sr.DepartureDate,
+		returnDate,
+		sr.Passengers.Adults,
+		sr.Passengers.Children,
+		sr.Passengers.Infants,
+		sr.CabinClass,
+		provider,
+	)
+}
+
 type SortOption struct {
 	Key   consts.SortKey   `json:"key,omitempty"`   // "price", "duration", "departure_time", "arrival_time"
 	Order consts.SortOrder `json:"order,omitempty"` // "asc" or "desc"
@@ -75,4 +187,37 @@ type SearchMetadata struct {
 	ProvidersFailed    int  `json:"providers_failed"`
 	SearchTimeMs       int  `json:"search_time_ms"`
 	CacheHit           bool `json:"cache_hit"`
+	// Stale is set when the results outlived their soft TTL and are being
+	// refreshed in the background.
+	Stale bool `json:"stale"`
+
+	// Complete is false when a provider did not answer, either because it
+	// failed or because the search deadline hit first. DegradedProviders
+	// lists those providers.
+	Complete          bool     `json:"complete"`
+	DegradedProviders []string `json:"degraded_providers,omitempty"`
+
+	Providers []ProviderMetadata `json:"providers,omitempty"`
+}
+
+// Degraded reports whether some providers are missing from the results.
+func (m SearchMetadata) Degraded() bool {
+	return len(m.DegradedProviders) > 0
+}
+
+// ProviderMetadata reports how a single provider was queried. Attempts counts
+// retries; a hedged attempt sent a second request after the hedge delay.
+type ProviderMetadata struct {
+	Provider     string `json:"provider"`
+	Succeeded    bool   `json:"succeeded"`
+	ErrorCode    string `json:"error_code,omitempty"`
+	Flights      int    `json:"flights"`
+	Attempts     int    `json:"attempts"`
+	LatencyMs    int    `json:"latency_ms"`
+	Hedged       bool   `json:"hedged,omitempty"`
+	HedgeWon     bool   `json:"hedge_won,omitempty"`
+	HedgeSkipped bool   `json:"hedge_skipped,omitempty"`
+	// Cached is set when the provider's results came from the raw results
+	// cache instead of a provider call.
+	Cached bool `json:"cached,omitempty"`
 }
@@ -0,0 +1,13 @@
+package errors
+
+import (
+	"net/http"
+
+	"github.com/azcov/bookcabin_test/pkg/errorz"
+)
+
+var (
+	ErrCacheEntryNotFound   = &errorz.WrappedError{StatusCode: http.StatusNotFound, ErrCode: "not_found", Msg: "Cache entry not found"}
+	ErrCacheUnknownProvider = &errorz.WrappedError{StatusCode: http.StatusNotFound, ErrCode: "not_found", Msg: "Unknown cache provider"}
+	ErrInvalidCachePurge    = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_cache_purge", Msg: "Cache purge needs an origin and destination, a provider or both"}
+)
@@ -0,0 +1,15 @@
+package errors
+
+import (
+	"net/http"
+
+	"github.com/azcov/bookcabin_test/pkg/errorz"
+)
+
+var (
+	ErrChaosUnknownProvider = &errorz.WrappedError{StatusCode: http.StatusNotFound, ErrCode: "not_found", Msg: "Unknown chaos provider"}
+	ErrChaosInvalidRate     = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_chaos_config", Msg: "Chaos rates must be between 0 and 1 and add up to at most 1"}
+	ErrChaosInvalidLatency  = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_chaos_config", Msg: "Chaos latency must be non-negative with min not above max"}
+	ErrChaosInvalidDist     = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_chaos_config", Msg: "Chaos latency distribution must be one of uniform, normal or exponential"}
+	ErrChaosInvalidErrType  = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_chaos_config", Msg: "Chaos error type must be one of internal_error, rate_limit_exceeded or not_found"}
+)
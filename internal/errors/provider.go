@@ -16,6 +16,8 @@ var (
 	ErrAirAsiaRateLimitExceeded         = &errorz.WrappedError{StatusCode: http.StatusTooManyRequests, ErrCode: "rate_limit_exceeded", Msg: "Air Asia rate limit exceeded"}
 	ErrBatikAirNotFound                 = &errorz.WrappedError{StatusCode: http.StatusNotFound, ErrCode: "not_found", Msg: "Batik Air not found"}
 	ErrBatikAirRateLimitExceeded        = &errorz.WrappedError{StatusCode: http.StatusTooManyRequests, ErrCode: "rate_limit_exceeded", Msg: "Batik Air rate limit exceeded"}
+	ErrBatikAirInternalError            = &errorz.WrappedError{StatusCode: http.StatusInternalServerError, ErrCode: "internal_error", Msg: "Batik Air internal error"}
 	ErrGarudaIndonesiaNotFound          = &errorz.WrappedError{StatusCode: http.StatusNotFound, ErrCode: "not_found", Msg: "Garuda Indonesia not found"}
 	ErrGarudaIndonesiaRateLimitExceeded = &errorz.WrappedError{StatusCode: http.StatusTooManyRequests, ErrCode: "rate_limit_exceeded", Msg: "Garuda Indonesia rate limit exceeded"}
+	ErrGarudaIndonesiaInternalError     = &errorz.WrappedError{StatusCode: http.StatusInternalServerError, ErrCode: "internal_error", Msg: "Garuda Indonesia internal error"}
 )
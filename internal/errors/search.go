@@ -0,0 +1,21 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/pkg/errorz"
+)
+
+var (
+	ErrPassengersNegative            = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_passengers", Msg: "Passenger counts must not be negative"}
+	ErrPassengersAdultRequired       = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_passengers", Msg: "At least one adult passenger is required"}
+	ErrPassengersInfantsExceedAdults = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_passengers", Msg: "Each infant must travel on the lap of an adult"}
+	ErrInvalidCabinClass             = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_cabin_class", Msg: "Cabin class must be one of economy, premium_economy, business or first"}
+	ErrInvalidFareBrand              = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_fare_brand", Msg: "Fare brand must be one of lite, value or flex"}
+	ErrInvalidSearchMode             = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_search_mode", Msg: "Search mode must be one of wait_all or fast"}
+	ErrInvalidMaxWait                = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_search_mode", Msg: "Max wait must not be negative"}
+	ErrPassengersSeatedLimitExceeded = &errorz.WrappedError{StatusCode: http.StatusBadRequest, ErrCode: "invalid_passengers", Msg: fmt.Sprintf("At most %d seated passengers are allowed per search", consts.MaxSeatedPassengers)}
+	ErrSearchFailed                  = &errorz.WrappedError{StatusCode: http.StatusInternalServerError, ErrCode: "internal_error", Msg: "Search failed"}
+)
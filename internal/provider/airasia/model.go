@@ -4,14 +4,25 @@ import (
 	"strings"
 	"time"
 
+	"github.com/azcov/bookcabin_test/internal/catalog"
 	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
 	"github.com/azcov/bookcabin_test/internal/util"
-	"github.com/leekchan/accounting"
 )
 
 const ProviderName = "AirAsia"
 
+// bookingClassToCabinClass overrides the conventional RBD mapping: AirAsia
+// has no first or premium economy cabin and sells its Premium Flatbed under
+// first class letters.
+var bookingClassToCabinClass = map[string]consts.CabinClass{
+	"F": consts.CabinClassBusiness,
+	"A": consts.CabinClassBusiness,
+	"P": consts.CabinClassBusiness,
+	"W": consts.CabinClassEconomy,
+	"E": consts.CabinClassEconomy,
+}
+
 type StopInfo struct {
 	Airport         string `json:"airport"`
 	WaitTimeMinutes int    `json:"wait_time_minutes"`
@@ -36,66 +47,63 @@ type FlightInfo struct {
 func (f *FlightInfo) ToDomainFlightInfo() (domain.FlightInfo, error) {
 	airlineCode, _, _ := util.ParseFlightNumber(f.FlightCode)
 
-	departTs := f.DepartTime.Unix()
-	arriveTs := f.ArriveTime.Unix()
-
-	totalMinutes := int(f.DurationHours * 60)
-	formattedDuration := util.FormatDurationMinute(totalMinutes)
-	ac := accounting.Accounting{Symbol: "IDR", Precision: 0, Format: "%s %v", Thousand: ".", Decimal: ","}
-	formattedPrice := ac.FormatMoney(f.PriceIdr)
-
-	baggageInfo := strings.Split(f.BaggageNote, ",")
-
 	result := domain.FlightInfo{
-		ID:       f.FlightCode + "_" + f.Airline,
-		Provider: f.Airline,
-		Airline: domain.AirlineInfo{
-			Name: f.Airline,
-			Code: airlineCode,
-		},
+		ID:           f.FlightCode + "_" + f.Airline,
+		Provider:     f.Airline,
+		Airline:      catalog.AirlineInfo(airlineCode, f.Airline),
 		FlightNumber: f.FlightCode,
-		Departure: domain.AirportInfo{
-			Airport:   f.FromAirport,
-			City:      consts.AirportCodeToCity[f.FromAirport],
-			Datetime:  f.DepartTime,
-			Timestamp: departTs,
-		},
-		Arrival: domain.AirportInfo{
-			Airport:   f.ToAirport,
-			City:      consts.AirportCodeToCity[f.ToAirport],
-			Datetime:  f.ArriveTime,
-			Timestamp: arriveTs,
-		},
-		Duration: domain.DurationInfo{
-			TotalMinutes: totalMinutes,
-			Formatted:    formattedDuration,
-		},
-		Stops: len(f.Stops),
-		Price: domain.PriceInfo{
-			Amount:   f.PriceIdr,
-			Currency: "IDR",
-			Display:  formattedPrice,
-		},
+		Departure:    domain.NewAirportInfo(f.FromAirport, "", "", f.DepartTime),
+		Arrival:      domain.NewAirportInfo(f.ToAirport, "", "", f.ArriveTime),
+		// duration_hours is rounded by AirAsia, the timestamps are exact
+		Duration:       domain.NewDurationInfo(f.DepartTime, f.ArriveTime),
+		Stops:          len(f.Stops),
+		Price:          domain.NewPriceInfo("IDR", f.PriceIdr, 0, 0, 0),
 		AvailableSeats: f.Seats,
-		CabinClass:     f.CabinClass,
-		Aircraft:       nil,
+		CabinClass:     consts.ResolveCabinClass(bookingClassToCabinClass, f.CabinClass),
+		Aircraft:       catalog.AircraftInfo("", airlineCode),
 		Amenities:      []domain.AmenityInfo{},
-		// Baggage: domain.BaggageInfo{
-		// 	CarryOn: "Cabin baggage only",
-		// 	Checked: "Additional fee",
-		// },
+		Layovers:       []domain.Layover{},
+		Baggage:        parseBaggageNote(f.BaggageNote),
 	}
 
-	if len(baggageInfo) >= 2 {
-		result.Baggage = domain.BaggageInfo{
-			CarryOn: baggageInfo[0],
-			Checked: baggageInfo[1],
-		}
+	for _, s := range f.Stops {
+		result.Layovers = append(result.Layovers, domain.NewLayover(s.Airport, s.WaitTimeMinutes))
 	}
+	result.Segments = domain.NewSegmentsFromLayovers(f.FlightCode, result.Departure, result.Arrival, result.Duration, result.Aircraft, result.Layovers)
+
+	// AirAsia sells a single fare without checked baggage or seat selection
+	result.FareOffers = []domain.FareOffer{{
+		Brand:   consts.FareBrandLite,
+		Price:   result.Price,
+		Baggage: result.Baggage,
+	}}
+	result.SelectFareOffer("")
+
 	result.CalculateBestValueScore()
 	return result, nil
 }
 
+// parseBaggageNote normalizes notes such as "Cabin baggage only, checked bags
+// additional fee". AirAsia always includes one cabin bag; checked bags are
+// included only when the note does not mention a fee.
+func parseBaggageNote(note string) domain.BaggageInfo {
+	baggage := domain.BaggageInfo{
+		CarryOn: domain.NewBaggageAllowance(1, 0, true, nil),
+		Checked: domain.NewBaggageAllowance(0, 0, false, nil),
+	}
+	for _, part := range strings.Split(note, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		switch {
+		case strings.Contains(part, "cabin"):
+			baggage.CarryOn = domain.NewBaggageAllowance(1, util.ParseWeightKg(part), true, nil)
+		case strings.Contains(part, "checked"):
+			included := !strings.Contains(part, "fee")
+			baggage.Checked = domain.NewBaggageAllowance(0, util.ParseWeightKg(part), included, nil)
+		}
+	}
+	return baggage
+}
+
 type Response struct {
 	Status  string       `json:"status"`
 	Flights []FlightInfo `json:"flights"`
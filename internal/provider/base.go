@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/logger"
+	"github.com/azcov/bookcabin_test/pkg/tracing"
+)
+
+// Source fetches and decodes a provider's raw search response.
+type Source[T any] interface {
+	Fetch(ctx context.Context, input domain.SearchRequest) (T, error)
+}
+
+// Mapper converts a provider's raw search response to domain flights. It
+// returns the provider's not found error when the response reports a failure.
+type Mapper[T any] func(raw T) ([]domain.FlightInfo, error)
+
+// PayloadHook may rewrite a raw payload before it is decoded.
+type PayloadHook func(ctx context.Context, data []byte) []byte
+
+type fileSource[T any] struct {
+	path  string
+	hooks []PayloadHook
+}
+
+// NewFileSource returns a Source that decodes a mock JSON response from path,
+// passing the payload through hooks first.
+func NewFileSource[T any](path string, hooks ...PayloadHook) Source[T] {
+	return &fileSource[T]{path: path, hooks: hooks}
+}
+
+func (s *fileSource[T]) Fetch(ctx context.Context, input domain.SearchRequest) (T, error) {
+	var raw T
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return raw, err
+	}
+	for _, hook := range s.hooks {
+		data = hook(ctx, data)
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return raw, err
+	}
+	return raw, nil
+}
+
+type httpSource[T any] struct {
+	client *http.Client
+	url    string
+	hooks  []PayloadHook
+}
+
+// NewHTTPSource returns a Source that posts the search to a provider API at
+// url and decodes its JSON response, passing the payload through hooks first.
+// The trace context of the search is propagated as W3C traceparent.
+func NewHTTPSource[T any](client *http.Client, url string, hooks ...PayloadHook) Source[T] {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpSource[T]{client: client, url: url, hooks: hooks}
+}
+
+func (s *httpSource[T]) Fetch(ctx context.Context, input domain.SearchRequest) (raw T, err error) {
+	ctx, span := tracing.Start(ctx, "provider.http")
+	defer func() { tracing.End(span, err) }()
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return raw, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return raw, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.InjectHTTP(ctx, req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return raw, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return raw, fmt.Errorf("provider responded %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return raw, err
+	}
+	for _, hook := range s.hooks {
+		data = hook(ctx, data)
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return raw, err
+	}
+	return raw, nil
+}
+
+type baseProvider[T any] struct {
+	name   string
+	source Source[T]
+	mapper Mapper[T]
+}
+
+// NewBaseProvider returns an AirlineInterface that fetches from source, maps
+// the response with mapper and keeps the flights matching the search criteria.
+func NewBaseProvider[T any](name string, source Source[T], mapper Mapper[T]) AirlineInterface {
+	return &baseProvider[T]{
+		name:   name,
+		source: source,
+		mapper: mapper,
+	}
+}
+
+func (bp *baseProvider[T]) SearchFlights(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+	raw, err := bp.source.Fetch(ctx, input)
+	if err != nil {
+		logger.ErrorContext(ctx, "Provider fetch failed", "err", err)
+		return nil, err
+	}
+
+	flights, err := bp.mapper(raw)
+	if err != nil {
+		logger.ErrorContext(ctx, "Provider mapping failed", "err", err)
+		return nil, err
+	}
+
+	return FilterByCriteria(flights, input), nil
+}
+
+// MapFlights converts each provider flight with toDomain, failing on the
+// first flight that cannot be converted.
+func MapFlights[F any](items []F, toDomain func(*F) (domain.FlightInfo, error)) ([]domain.FlightInfo, error) {
+	flights := make([]domain.FlightInfo, 0, len(items))
+	for i := range items {
+		flight, err := toDomain(&items[i])
+		if err != nil {
+			return nil, err
+		}
+		flights = append(flights, flight)
+	}
+	return flights, nil
+}
+
+// MatchesCriteria reports whether a flight serves the requested route, date,
+// cabin and passenger count.
+func MatchesCriteria(flight domain.FlightInfo, input domain.SearchRequest) bool {
+	cabinClass, _ := consts.ParseCabinClass(string(input.CabinClass))
+	return flight.Departure.Airport == input.Origin &&
+		flight.Arrival.Airport == input.Destination &&
+		flight.Departure.LocalDate() == input.DepartureDate &&
+		flight.AvailableSeats >= input.Passengers.Seated() &&
+		flight.CabinClass == cabinClass
+}
+
+// FilterByCriteria keeps the flights matching the search criteria and prices
+// them for the requested passengers.
+func FilterByCriteria(flights []domain.FlightInfo, input domain.SearchRequest) []domain.FlightInfo {
+	result := []domain.FlightInfo{}
+	for _, f := range flights {
+		if !MatchesCriteria(f, input) {
+			continue
+		}
+		f.CalculateTotalPrice(input.Passengers)
+		result = append(result, f)
+	}
+	return result
+}
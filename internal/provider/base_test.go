@@ -0,0 +1,257 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/chaos"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/azcov/bookcabin_test/pkg/randz"
+	"github.com/azcov/bookcabin_test/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubResponse struct {
+	OK      bool
+	Flights []domain.FlightInfo
+}
+
+type stubSource struct {
+	resp *stubResponse
+	err  error
+}
+
+func (s *stubSource) Fetch(ctx context.Context, input domain.SearchRequest) (*stubResponse, error) {
+	return s.resp, s.err
+}
+
+var errStubNotFound = errors.New("stub not found")
+
+func mapStubResponse(raw *stubResponse) ([]domain.FlightInfo, error) {
+	if raw == nil || !raw.OK {
+		return nil, errStubNotFound
+	}
+	return raw.Flights, nil
+}
+
+func stubFlight(id, origin, destination string, departure time.Time, seats int) domain.FlightInfo {
+	return domain.FlightInfo{
+		ID:             id,
+		Departure:      domain.NewAirportInfo(origin, "", "", departure),
+		Arrival:        domain.NewAirportInfo(destination, "", "", departure.Add(2*time.Hour)),
+		AvailableSeats: seats,
+		CabinClass:     consts.CabinClassEconomy,
+		Price:          domain.NewPriceInfo("IDR", 1000000, 0, 0, 0),
+	}
+}
+
+func TestBaseProvider_SearchFlights(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	flights := []domain.FlightInfo{
+		stubFlight("f1", "CGK", "DPS", time.Date(2025, 12, 15, 6, 0, 0, 0, wib), 10),
+		// 2025-12-14 18:30 UTC is already 2025-12-15 in Jakarta
+		stubFlight("f2", "CGK", "DPS", time.Date(2025, 12, 14, 18, 30, 0, 0, time.UTC), 10),
+		stubFlight("f3", "CGK", "DPS", time.Date(2025, 12, 16, 6, 0, 0, 0, wib), 10),
+		stubFlight("f4", "CGK", "SUB", time.Date(2025, 12, 15, 6, 0, 0, 0, wib), 10),
+		stubFlight("f5", "CGK", "DPS", time.Date(2025, 12, 15, 9, 0, 0, 0, wib), 1),
+	}
+	request := domain.SearchRequest{
+		Origin:        "CGK",
+		Destination:   "DPS",
+		DepartureDate: "2025-12-15",
+		Passengers:    domain.PassengerCount{Adults: 2},
+		CabinClass:    "economy",
+	}
+
+	tests := []struct {
+		name          string
+		source        *stubSource
+		expectedIDs   []string
+		expectedError error
+	}{
+		{
+			name:        "Filters by criteria",
+			source:      &stubSource{resp: &stubResponse{OK: true, Flights: flights}},
+			expectedIDs: []string{"f1", "f2"},
+		},
+		{
+			name:        "No match returns empty slice",
+			source:      &stubSource{resp: &stubResponse{OK: true}},
+			expectedIDs: []string{},
+		},
+		{
+			name:          "Mapper error",
+			source:        &stubSource{resp: &stubResponse{OK: false}},
+			expectedError: errStubNotFound,
+		},
+		{
+			name:          "Source error",
+			source:        &stubSource{err: errors.New("read failed")},
+			expectedError: errors.New("read failed"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewBaseProvider("stub", tt.source, mapStubResponse)
+			resp, err := p.SearchFlights(context.Background(), request)
+
+			assert.Equal(t, tt.expectedError, err)
+			if tt.expectedError != nil {
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NotNil(t, resp)
+			ids := make([]string, 0, len(resp))
+			for _, f := range resp {
+				ids = append(ids, f.ID)
+				assert.Equal(t, 2000000, f.Price.Total)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	record := func(name string) Middleware {
+		return func(next AirlineInterface) AirlineInterface {
+			return AirlineFunc(func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+				order = append(order, name)
+				return next.SearchFlights(ctx, input)
+			})
+		}
+	}
+	base := AirlineFunc(func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+		order = append(order, "base")
+		return []domain.FlightInfo{}, nil
+	})
+
+	_, err := Chain(base, record("outer"), record("inner")).SearchFlights(context.Background(), domain.SearchRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner", "base"}, order)
+}
+
+func TestMiddlewares(t *testing.T) {
+	errLimited := errors.New("limited")
+	errFailed := errors.New("failed")
+	base := &MockAirline{Flights: []domain.FlightInfo{{ID: "f1"}}}
+
+	t.Run("RateLimitExceeded", func(t *testing.T) {
+		p := Chain(base, WithRateLimit(consts.ProviderKeyAirAsia, ratelimit.NewWithDuration(1, time.Hour), errLimited))
+
+		_, err := p.SearchFlights(context.Background(), domain.SearchRequest{})
+		assert.NoError(t, err)
+		_, err = p.SearchFlights(context.Background(), domain.SearchRequest{})
+		assert.Equal(t, errLimited, err)
+	})
+
+	// floats pins the latency draw and then the fault draw of each call
+	chaosProvider := func(pc chaos.ProviderConfig, floats ...float64) (AirlineInterface, *clock.Fake) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		rnd := randz.NewFake(floats...)
+		injector := chaos.NewInjector(chaos.Config{Enabled: true, AirAsia: pc}, func(int64) randz.Rand { return rnd })
+		return Chain(base, WithChaos(injector, clk, consts.ProviderKeyAirAsia, map[chaos.ErrorType]error{
+			chaos.ErrorTypeInternal:  errFailed,
+			chaos.ErrorTypeRateLimit: errLimited,
+		})), clk
+	}
+
+	t.Run("ChaosError", func(t *testing.T) {
+		p, _ := chaosProvider(chaos.ProviderConfig{ErrorRate: 1, ErrorType: chaos.ErrorTypeRateLimit})
+
+		_, err := p.SearchFlights(context.Background(), domain.SearchRequest{})
+		assert.Equal(t, errLimited, err)
+	})
+
+	t.Run("ChaosError_DefaultsToInternal", func(t *testing.T) {
+		p, _ := chaosProvider(chaos.ProviderConfig{ErrorRate: 1})
+
+		_, err := p.SearchFlights(context.Background(), domain.SearchRequest{})
+		assert.Equal(t, errFailed, err)
+	})
+
+	t.Run("ChaosErrorRate_Pinned", func(t *testing.T) {
+		p, _ := chaosProvider(chaos.ProviderConfig{ErrorRate: 0.1}, 0, 0.05, 0, 0.5)
+
+		_, err := p.SearchFlights(context.Background(), domain.SearchRequest{})
+		assert.Equal(t, errFailed, err)
+		flights, err := p.SearchFlights(context.Background(), domain.SearchRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, flights, 1)
+	})
+
+	t.Run("ChaosDisabled", func(t *testing.T) {
+		injector := chaos.NewInjector(chaos.Config{AirAsia: chaos.ProviderConfig{ErrorRate: 1}}, nil)
+		p := Chain(base, WithChaos(injector, clock.New(), consts.ProviderKeyAirAsia, nil))
+
+		flights, err := p.SearchFlights(context.Background(), domain.SearchRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, flights, 1)
+	})
+
+	t.Run("ChaosTimeout_WaitsForDeadline", func(t *testing.T) {
+		p, clk := chaosProvider(chaos.ProviderConfig{TimeoutRate: 1})
+		ctx, cancel := clk.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := p.SearchFlights(ctx, domain.SearchRequest{})
+			done <- err
+		}()
+		clk.BlockUntil(1)
+		clk.Advance(2 * time.Second)
+
+		assert.ErrorIs(t, <-done, context.DeadlineExceeded)
+	})
+
+	t.Run("ChaosLatency_WaitsOnClock", func(t *testing.T) {
+		// 30ms + 0.5 * 10ms
+		p, clk := chaosProvider(chaos.ProviderConfig{LatencyMinMs: 30, LatencyMaxMs: 40}, 0.5, 0.5)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := p.SearchFlights(context.Background(), domain.SearchRequest{})
+			done <- err
+		}()
+		clk.BlockUntil(1)
+		clk.Advance(34 * time.Millisecond)
+		select {
+		case <-done:
+			t.Fatal("search returned before the simulated latency")
+		default:
+		}
+		clk.Advance(time.Millisecond)
+
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("ChaosLatency_HonorsCancellation", func(t *testing.T) {
+		p, _ := chaosProvider(chaos.ProviderConfig{LatencyMinMs: 500, LatencyMaxMs: 600})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		flights, err := p.SearchFlights(ctx, domain.SearchRequest{})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, flights)
+	})
+}
+
+func TestAirAsiaProvider_ChaosMalformedPayload(t *testing.T) {
+	injector := chaos.NewInjector(chaos.Config{
+		Enabled: true,
+		Seed:    1,
+		AirAsia: chaos.ProviderConfig{MalformedRate: 1},
+	}, nil)
+	p := NewAirAsiaProvider("./mock", ratelimit.NewUnlimited(), injector, clock.New())
+
+	flights, err := p.SearchFlights(context.Background(), domain.SearchRequest{})
+	assert.Error(t, err)
+	assert.Nil(t, flights)
+}
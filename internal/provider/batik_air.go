@@ -1,101 +1,32 @@
 package provider
 
 import (
-	"context"
-	"encoding/json"
-	"os"
-	"strings"
-	"time"
-
+	"github.com/azcov/bookcabin_test/internal/chaos"
+	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
 	"github.com/azcov/bookcabin_test/internal/errors"
 	batikair "github.com/azcov/bookcabin_test/internal/provider/batik_air"
-	"github.com/azcov/bookcabin_test/internal/util"
-	"github.com/azcov/bookcabin_test/pkg/logger"
+	"github.com/azcov/bookcabin_test/pkg/clock"
 	"github.com/azcov/bookcabin_test/pkg/ratelimit"
 )
 
-type batikAirProvider struct {
-	fileDir string
-	rl      ratelimit.Limiter
+func NewBatikAirProvider(fileDir string, rl ratelimit.Limiter, injector *chaos.Injector, clk clock.Clock) AirlineInterface {
+	source := NewFileSource[*batikair.Response](fileDir+"/batik_air_search_response.json", ChaosPayloadHook)
+	return Chain(
+		NewBaseProvider(batikair.ProviderName, source, mapBatikAirResponse),
+		WithRateLimit(consts.ProviderKeyBatikAir, rl, errors.ErrBatikAirRateLimitExceeded),
+		WithChaos(injector, clk, consts.ProviderKeyBatikAir, map[chaos.ErrorType]error{
+			chaos.ErrorTypeInternal:  errors.ErrBatikAirInternalError,
+			chaos.ErrorTypeRateLimit: errors.ErrBatikAirRateLimitExceeded,
+			chaos.ErrorTypeNotFound:  errors.ErrBatikAirNotFound,
+		}),
+	)
 }
 
-func NewBatikAirProvider(fileDir string) AirlineInterface {
-	return &batikAirProvider{
-		fileDir: fileDir,
-		rl:      ratelimit.NewWithDuration(100, time.Second),
-	}
-}
-
-func (ap *batikAirProvider) SearchFlights(ctx context.Context, input domain.SearchRequest) (flights []domain.FlightInfo, err error) {
-	if !ap.rl.Allow() {
-		return nil, errors.ErrBatikAirRateLimitExceeded
-	}
-	// Implementation for searching flights from Batik Air
-	start := time.Now()
-	minDelay := 200
-	maxDelay := 400
-
-	defer func() {
-		elapsed := time.Since(start)
-		delay := util.RandomDuration(minDelay, maxDelay)
-		wait := delay - elapsed
-		if wait > 0 {
-			select {
-			case <-time.After(wait):
-			case <-ctx.Done():
-				err = ctx.Err()
-			}
-		}
-	}()
-
-	flights, err = ap.callSearch(ctx, input)
-	if err != nil {
-		logger.ErrorContext(ctx, "Error : ", "err", err)
-		return nil, err
-	}
-
-	return flights, nil
-}
-
-// callSearch simulates calling the Batik Air search API and returns filtered mock data.
-func (ap *batikAirProvider) callSearch(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
-	var flights []domain.FlightInfo
-	// Load mock file
-	data, err := os.ReadFile(ap.fileDir + "/batik_air_search_response.json")
-	if err != nil {
-		logger.ErrorContext(ctx, "Error : ", "err", err)
-		return nil, err
-	}
-
-	// Parse JSON
-	var raw *batikair.Response
-	if err := json.Unmarshal(data, &raw); err != nil {
-		logger.ErrorContext(ctx, "Error : ", "err", err)
-		return nil, err
-	}
-
+// mapBatikAirResponse maps the Batik Air search API response to domain flights.
+func mapBatikAirResponse(raw *batikair.Response) ([]domain.FlightInfo, error) {
 	if raw == nil || raw.Code != 200 {
-		logger.ErrorContext(ctx, "Error : ", "err", err)
 		return nil, errors.ErrBatikAirNotFound
 	}
-
-	for _, f := range raw.Results {
-		domainFlight, err := f.ToDomainFlightInfo()
-		if err != nil {
-			logger.ErrorContext(ctx, "Error : ", "err", err)
-			return nil, err
-		}
-
-		// Filter by input criteria
-		if domainFlight.Departure.Airport == input.Origin &&
-			domainFlight.Arrival.Airport == input.Destination &&
-			domainFlight.Departure.Datetime.Format("2006-01-02") == input.DepartureDate &&
-			domainFlight.AvailableSeats >= input.Passengers &&
-			strings.EqualFold(domainFlight.CabinClass, input.CabinClass) {
-			flights = append(flights, domainFlight)
-		}
-	}
-
-	return flights, nil
+	return MapFlights(raw.Results, (*batikair.FlightInfo).ToDomainFlightInfo)
 }
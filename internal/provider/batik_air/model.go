@@ -4,19 +4,33 @@ import (
 	"strings"
 	"time"
 
+	"github.com/azcov/bookcabin_test/internal/catalog"
 	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/util"
 	"github.com/azcov/bookcabin_test/pkg/logger"
-	"github.com/leekchan/accounting"
 )
 
 const ProviderName = "Batik Air"
 
 var (
-	classToCabinClass = map[string]string{
-		"Y": "Economy",
-		// "X":   "Business",
-		// "Z": "First Class",
+	// classToCabinClass overrides the conventional RBD mapping: Batik Air has
+	// no first or premium economy cabin.
+	classToCabinClass = map[string]consts.CabinClass{
+		"F": consts.CabinClassBusiness,
+		"A": consts.CabinClassBusiness,
+		"P": consts.CabinClassBusiness,
+		"W": consts.CabinClassEconomy,
+		"E": consts.CabinClassEconomy,
+	}
+
+	onboardServiceToAmenity = map[string]consts.AmenityType{
+		"wifi":          consts.AmenityTypeWifi,
+		"meal":          consts.AmenityTypeMeal,
+		"snack":         consts.AmenityTypeSnack,
+		"beverage":      consts.AmenityTypeBeverage,
+		"entertainment": consts.AmenityTypeEntertainment,
+		"usb power":     consts.AmenityTypePower,
 	}
 
 	TimeFormat = "2006-01-02T15:04:05Z0700" // "RFC3339     = "2006-01-02T15:04:05Z07:00""
@@ -65,76 +79,64 @@ func (f *FlightInfo) ToDomainFlightInfo() (domain.FlightInfo, error) {
 		logger.Error("Error : ", "err", err)
 		return domain.FlightInfo{}, err
 	}
-	departTs := departTime.Unix()
-	arriveTs := arriveTime.Unix()
-
-	// totalMinutes := int(f.TravelTime * 60)
-	// formattedDuration := util.FormatDurationMinute(totalMinutes)
-
-	totalMinutes := arriveTime.Sub(departTime).Minutes()
-	ac := accounting.Accounting{Symbol: f.Fare.CurrencyCode, Precision: 0, Format: "%s %v", Thousand: ".", Decimal: ","}
-	formattedPrice := ac.FormatMoney(f.Fare.TotalPrice)
-	baggageInfo := strings.Split(f.BaggageInfo, ",")
+	// Anything in the total not covered by base fare and taxes is a fee
+	fees := max(f.Fare.TotalPrice-f.Fare.BasePrice-f.Fare.Taxes, 0)
 
 	result := domain.FlightInfo{
-		ID:       f.FlightNumber + "_" + f.AirlineName,
-		Provider: f.AirlineName,
-		Airline: domain.AirlineInfo{
-			Name: f.AirlineName,
-			Code: f.AirlineIATA,
-		},
-		FlightNumber: f.FlightNumber,
-		Departure: domain.AirportInfo{
-			Airport:   f.Origin,
-			City:      consts.AirportCodeToCity[f.Origin],
-			Datetime:  departTime,
-			Timestamp: departTs,
-		},
-		Arrival: domain.AirportInfo{
-			Airport:   f.Destination,
-			City:      consts.AirportCodeToCity[f.Destination],
-			Datetime:  arriveTime,
-			Timestamp: arriveTs,
-		},
-		Duration: domain.DurationInfo{
-			TotalMinutes: int(totalMinutes),
-			Formatted:    f.TravelTime,
-		},
-		Stops: f.NumberOfStops,
-		Price: domain.PriceInfo{
-			Amount:   f.Fare.TotalPrice,
-			Currency: f.Fare.CurrencyCode,
-			Display:  formattedPrice,
-		},
+		ID:             f.FlightNumber + "_" + f.AirlineName,
+		Provider:       f.AirlineName,
+		Airline:        catalog.AirlineInfo(f.AirlineIATA, f.AirlineName),
+		FlightNumber:   f.FlightNumber,
+		Departure:      domain.NewAirportInfo(f.Origin, "", "", departTime),
+		Arrival:        domain.NewAirportInfo(f.Destination, "", "", arriveTime),
+		Duration:       domain.NewDurationInfo(departTime, arriveTime),
+		Stops:          f.NumberOfStops,
+		Price:          domain.NewPriceInfo(f.Fare.CurrencyCode, f.Fare.TotalPrice, f.Fare.BasePrice, f.Fare.Taxes, fees),
 		AvailableSeats: f.SeatsAvailable,
-		CabinClass:     classToCabinClass[f.Fare.Class],
-		Aircraft: &domain.AircraftInfo{
-			Model: f.AircraftModel,
-			Code:  "",
-		},
-		Amenities: []domain.AmenityInfo{},
-		// Baggage: domain.BaggageInfo{
-		// 	CarryOn: "Cabin baggage only",
-		// 	Checked: "Additional fee",
-		// },
+		CabinClass:     consts.ResolveCabinClass(classToCabinClass, f.Fare.Class),
+		Aircraft:       catalog.AircraftInfo(f.AircraftModel, f.AirlineIATA),
+		Amenities:      []domain.AmenityInfo{},
+		Layovers:       []domain.Layover{},
+		Baggage:        parseBaggageInfo(f.BaggageInfo),
 	}
 
-	if len(baggageInfo) >= 2 {
-		result.Baggage = domain.BaggageInfo{
-			CarryOn: baggageInfo[0],
-			Checked: baggageInfo[1],
-		}
+	for _, c := range f.Connections {
+		result.Layovers = append(result.Layovers, domain.NewLayover(c.StopAirport, util.FormatDurationString(c.StopDuration)))
 	}
+	result.Segments = domain.NewSegmentsFromLayovers(f.FlightNumber, result.Departure, result.Arrival, result.Duration, result.Aircraft, result.Layovers)
+
 	for _, amenity := range f.OnboardServices {
-		result.Amenities = append(result.Amenities, domain.AmenityInfo{
-			Type:        amenity,
-			Description: amenity,
-		})
+		result.Amenities = append(result.Amenities, domain.NewAmenityInfo(onboardServiceToAmenity, amenity))
 	}
+	result.FareOffers = []domain.FareOffer{{
+		Brand:     consts.FareBrandValue,
+		FareBasis: f.Fare.Class,
+		Price:     result.Price,
+		Baggage:   result.Baggage,
+	}}
+	result.SelectFareOffer("")
+
 	result.CalculateBestValueScore()
 	return result, nil
 }
 
+// parseBaggageInfo normalizes notes such as "7kg cabin, 20kg checked". A part
+// naming a weight is included in the fare.
+func parseBaggageInfo(info string) domain.BaggageInfo {
+	var baggage domain.BaggageInfo
+	for _, part := range strings.Split(info, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		kg := util.ParseWeightKg(part)
+		switch {
+		case strings.Contains(part, "cabin"):
+			baggage.CarryOn = domain.NewBaggageAllowance(1, kg, kg > 0, nil)
+		case strings.Contains(part, "checked"):
+			baggage.Checked = domain.NewBaggageAllowance(0, kg, kg > 0, nil)
+		}
+	}
+	return baggage
+}
+
 type Response struct {
 	Code    int          `json:"code"`
 	Message string       `json:"message"`
@@ -5,11 +5,13 @@ import (
 	"testing"
 
 	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/azcov/bookcabin_test/pkg/ratelimit"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestBatikAirProvider_SearchFlights(t *testing.T) {
-	batikAirProvider := NewBatikAirProvider("./mock")
+	batikAirProvider := NewBatikAirProvider("./mock", ratelimit.NewUnlimited(), nil, clock.New())
 	_, err := batikAirProvider.SearchFlights(context.Background(), domain.SearchRequest{})
 	assert.NoError(t, err)
 
@@ -28,7 +30,7 @@ func TestBatikAirProvider_SearchFlights(t *testing.T) {
 				Origin:        "CGK",
 				Destination:   "DPS",
 				DepartureDate: "2025-12-15",
-				Passengers:    1,
+				Passengers:    domain.PassengerCount{Adults: 1},
 				CabinClass:    "Economy",
 			},
 			expectedFlights: 3,
@@ -41,7 +43,7 @@ func TestBatikAirProvider_SearchFlights(t *testing.T) {
 				Origin:        "BDO",
 				Destination:   "DPS",
 				DepartureDate: "2025-12-15",
-				Passengers:    1,
+				Passengers:    domain.PassengerCount{Adults: 1},
 				CabinClass:    "Economy",
 			},
 			expectedFlights: 0,
@@ -1,14 +1,16 @@
 package garudaindonesia
 
 import (
-	"fmt"
+	"strings"
 	"time"
 
+	"github.com/azcov/bookcabin_test/internal/catalog"
+	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
-	"github.com/azcov/bookcabin_test/internal/util"
-	"github.com/leekchan/accounting"
 )
 
+const ProviderName = "Garuda Indonesia"
+
 type AirportInfo struct {
 	Airport  string    `json:"airport"`
 	City     string    `json:"city"`
@@ -26,6 +28,61 @@ type BaggageInfo struct {
 	Checked int `json:"checked"`
 }
 
+// toDomain normalizes Garuda's allowances, which are piece counts.
+func (b BaggageInfo) toDomain() domain.BaggageInfo {
+	return domain.BaggageInfo{
+		CarryOn: domain.NewBaggageAllowance(b.CarryOn, 0, b.CarryOn > 0, nil),
+		Checked: domain.NewBaggageAllowance(b.Checked, 0, b.Checked > 0, nil),
+	}
+}
+
+// bookingClassToCabinClass overrides the conventional RBD mapping: Garuda
+// has no premium economy cabin.
+var bookingClassToCabinClass = map[string]consts.CabinClass{
+	"W": consts.CabinClassEconomy,
+	"E": consts.CabinClassEconomy,
+}
+
+var amenityToType = map[string]consts.AmenityType{
+	"wifi":          consts.AmenityTypeWifi,
+	"meal":          consts.AmenityTypeMeal,
+	"power_outlet":  consts.AmenityTypePower,
+	"entertainment": consts.AmenityTypeEntertainment,
+	"extra_legroom": consts.AmenityTypeExtraLegroom,
+}
+
+var fareFamilyToBrand = map[string]consts.FareBrand{
+	"saver":   consts.FareBrandLite,
+	"classic": consts.FareBrandValue,
+	"flex":    consts.FareBrandFlex,
+}
+
+type FareFamilyInfo struct {
+	Name          string      `json:"name"`
+	FareBasis     string      `json:"fare_basis"`
+	Price         PriceInfo   `json:"price"`
+	Refundable    bool        `json:"refundable"`
+	ChangeFee     *int        `json:"change_fee,omitempty"`
+	SeatSelection bool        `json:"seat_selection"`
+	Baggage       BaggageInfo `json:"baggage"`
+}
+
+// toDomainFareOffer converts a fare family, reporting false for families
+// without a known brand, which cannot be requested or compared across
+// airlines.
+func (ff *FareFamilyInfo) toDomainFareOffer() (domain.FareOffer, bool) {
+	brand, ok := fareFamilyToBrand[strings.ToLower(ff.Name)]
+	return domain.FareOffer{
+		Brand:                 brand,
+		FareBasis:             ff.FareBasis,
+		Price:                 domain.NewPriceInfo(ff.Price.Currency, ff.Price.Amount, 0, 0, 0),
+		Refundable:            ff.Refundable,
+		ChangeFee:             ff.ChangeFee,
+		Baggage:               ff.Baggage.toDomain(),
+		SeatSelectionIncluded: ff.SeatSelection,
+	}, ok
+}
+
 type TimeInfo struct {
 	Airport string    `json:"airport"`
 	Time    time.Time `json:"time"`
@@ -39,83 +96,89 @@ type SegmentInfo struct {
 	LayoverMinutes  int      `json:"layover_minutes,omitempty"`
 }
 
+func (s *SegmentInfo) toDomainSegment(aircraft *domain.AircraftInfo) domain.Segment {
+	return domain.Segment{
+		FlightNumber: s.FlightNumber,
+		Departure:    domain.NewAirportInfo(s.Departure.Airport, "", "", s.Departure.Time),
+		Arrival:      domain.NewAirportInfo(s.Arrival.Airport, "", "", s.Arrival.Time),
+		Duration:     domain.NewDurationInfo(s.Departure.Time, s.Arrival.Time),
+		Aircraft:     aircraft,
+	}
+}
+
 type FlightInfo struct {
-	FlightID        string        `json:"flight_id"`
-	Airline         string        `json:"airline"`
-	AirlineCode     string        `json:"airline_code"`
-	Departure       AirportInfo   `json:"departure"`
-	Arrival         AirportInfo   `json:"arrival"`
-	DurationMinutes int           `json:"duration_minutes"`
-	Stops           int           `json:"stops"`
-	Aircraft        string        `json:"aircraft"`
-	Price           PriceInfo     `json:"price"`
-	AvailableSeats  int           `json:"available_seats"`
-	FareClass       string        `json:"fare_class"`
-	Baggage         BaggageInfo   `json:"baggage"`
-	Amenities       []string      `json:"amenities,omitempty"`
-	Segments        []SegmentInfo `json:"segments,omitempty"`
+	FlightID        string           `json:"flight_id"`
+	Airline         string           `json:"airline"`
+	AirlineCode     string           `json:"airline_code"`
+	Departure       AirportInfo      `json:"departure"`
+	Arrival         AirportInfo      `json:"arrival"`
+	DurationMinutes int              `json:"duration_minutes"`
+	Stops           int              `json:"stops"`
+	Aircraft        string           `json:"aircraft"`
+	Price           PriceInfo        `json:"price"`
+	AvailableSeats  int              `json:"available_seats"`
+	FareClass       string           `json:"fare_class"`
+	Baggage         BaggageInfo      `json:"baggage"`
+	Amenities       []string         `json:"amenities,omitempty"`
+	Segments        []SegmentInfo    `json:"segments,omitempty"`
+	FareFamilies    []FareFamilyInfo `json:"fare_families,omitempty"`
 }
 
 func (f *FlightInfo) ToDomainFlightInfo() (domain.FlightInfo, error) {
-	// airlineCode, _, _ := util.ParseFlightNumber(f.FlightNumber)
-	// departLocation := f.Departure.Time.Location()
-
-	departTs := f.Departure.Time.Unix()
-	arriveTs := f.Arrival.Time.Unix()
-
-	formattedDuration := util.FormatDurationMinute(f.DurationMinutes)
-
-	ac := accounting.Accounting{Symbol: f.Price.Currency, Precision: 0, Format: "%s %v", Thousand: ".", Decimal: ","}
-	formattedPrice := ac.FormatMoney(f.Price.Amount)
-
 	result := domain.FlightInfo{
-		ID:       f.FlightID + "_" + f.Airline,
-		Provider: f.Airline,
-		Airline: domain.AirlineInfo{
-			Name: f.Airline,
-			Code: f.AirlineCode,
-		},
-		FlightNumber: f.FlightID,
-		Departure: domain.AirportInfo{
-			Airport:   f.Departure.Airport,
-			City:      f.Departure.City,
-			Datetime:  f.Departure.Time,
-			Timestamp: departTs,
-		},
-		Arrival: domain.AirportInfo{
-			Airport:   f.Arrival.Airport,
-			City:      f.Arrival.City,
-			Datetime:  f.Arrival.Time, // convert to depart timezone
-			Timestamp: arriveTs,
-		},
-		Duration: domain.DurationInfo{
-			TotalMinutes: f.DurationMinutes,
-			Formatted:    formattedDuration,
-		},
-		Stops: f.Stops,
-		Price: domain.PriceInfo{
-			Amount:   f.Price.Amount,
-			Currency: f.Price.Currency,
-			Display:  formattedPrice,
-		},
+		ID:             f.FlightID + "_" + f.Airline,
+		Provider:       f.Airline,
+		Airline:        catalog.AirlineInfo(f.AirlineCode, f.Airline),
+		FlightNumber:   f.FlightID,
+		Departure:      domain.NewAirportInfo(f.Departure.Airport, f.Departure.City, f.Departure.Terminal, f.Departure.Time),
+		Arrival:        domain.NewAirportInfo(f.Arrival.Airport, f.Arrival.City, f.Arrival.Terminal, f.Arrival.Time),
+		Duration:       domain.NewDurationInfo(f.Departure.Time, f.Arrival.Time),
+		Stops:          f.Stops,
+		Price:          domain.NewPriceInfo(f.Price.Currency, f.Price.Amount, 0, 0, 0),
 		AvailableSeats: f.AvailableSeats,
-		CabinClass:     f.FareClass,
-		Aircraft: &domain.AircraftInfo{
-			Model: f.Aircraft,
-			Code:  "",
-		},
-		Amenities: []domain.AmenityInfo{},
-		Baggage: domain.BaggageInfo{
-			CarryOn: fmt.Sprintf("%dkg cabin", f.Baggage.CarryOn),
-			Checked: fmt.Sprintf("%dkg checked", f.Baggage.Checked),
-		},
+		CabinClass:     consts.ResolveCabinClass(bookingClassToCabinClass, f.FareClass),
+		Aircraft:       catalog.AircraftInfo(f.Aircraft, f.AirlineCode),
+		Amenities:      []domain.AmenityInfo{},
+		Layovers:       []domain.Layover{},
+		Baggage:        f.Baggage.toDomain(),
 	}
+	if len(f.Segments) > 0 {
+		result.Segments = make([]domain.Segment, 0, len(f.Segments))
+		for i, s := range f.Segments {
+			if i > 0 {
+				result.Layovers = append(result.Layovers, domain.NewLayover(s.Departure.Airport, s.LayoverMinutes))
+			}
+			result.Segments = append(result.Segments, s.toDomainSegment(result.Aircraft))
+		}
+
+		// The top-level arrival only covers the first leg of a connecting flight
+		result.Arrival = result.Segments[len(result.Segments)-1].Arrival
+		result.Stops = len(result.Layovers)
+		result.Duration = domain.NewDurationInfo(f.Departure.Time, f.Segments[len(f.Segments)-1].Arrival.Time)
+	} else {
+		result.Segments = domain.NewSegmentsFromLayovers(f.FlightID, result.Departure, result.Arrival, result.Duration, result.Aircraft, nil)
+	}
+
 	for _, amenity := range f.Amenities {
-		result.Amenities = append(result.Amenities, domain.AmenityInfo{
-			Type:        amenity,
-			Description: amenity,
-		})
+		result.Amenities = append(result.Amenities, domain.NewAmenityInfo(amenityToType, amenity))
 	}
+	// The top-level price is the default fare, families list every brand on sale
+	result.FareOffers = []domain.FareOffer{{
+		Brand:   consts.FareBrandValue,
+		Price:   result.Price,
+		Baggage: result.Baggage,
+	}}
+	offers := make([]domain.FareOffer, 0, len(f.FareFamilies))
+	for _, ff := range f.FareFamilies {
+		if offer, ok := ff.toDomainFareOffer(); ok {
+			offers = append(offers, offer)
+		}
+	}
+	if len(offers) > 0 {
+		result.FareOffers = offers
+	}
+	result.SelectFareOffer("")
+
 	result.CalculateBestValueScore()
 	return result, nil
 }
@@ -2,14 +2,18 @@ package provider
 
 import (
 	"context"
+	"slices"
 	"testing"
 
 	"github.com/azcov/bookcabin_test/internal/domain"
+	garudaindonesia "github.com/azcov/bookcabin_test/internal/provider/garuda_indonesia"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/azcov/bookcabin_test/pkg/ratelimit"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGarudaIndonesiaProvider_SearchFlights(t *testing.T) {
-	garudaIndonesiaProvider := NewGarudaIndonesiaProvider("./mock")
+	garudaIndonesiaProvider := NewGarudaIndonesiaProvider("./mock", ratelimit.NewUnlimited(), nil, clock.New())
 	_, err := garudaIndonesiaProvider.SearchFlights(context.Background(), domain.SearchRequest{})
 	assert.NoError(t, err)
 
@@ -28,7 +32,7 @@ func TestGarudaIndonesiaProvider_SearchFlights(t *testing.T) {
 				Origin:        "CGK",
 				Destination:   "DPS",
 				DepartureDate: "2025-12-15",
-				Passengers:    1,
+				Passengers:    domain.PassengerCount{Adults: 1},
 				CabinClass:    "Economy",
 			},
 			expectedFlights: 3,
@@ -41,7 +45,7 @@ func TestGarudaIndonesiaProvider_SearchFlights(t *testing.T) {
 				Origin:        "BDO",
 				Destination:   "DPS",
 				DepartureDate: "2025-12-15",
-				Passengers:    1,
+				Passengers:    domain.PassengerCount{Adults: 1},
 				CabinClass:    "Economy",
 			},
 			expectedFlights: 0,
@@ -62,3 +66,31 @@ func TestGarudaIndonesiaProvider_SearchFlights(t *testing.T) {
 		})
 	}
 }
+
+func TestGarudaIndonesiaProvider_UnknownFareFamily(t *testing.T) {
+	raw, err := NewFileSource[*garudaindonesia.Response]("./mock/garuda_indonesia_search_response.json").Fetch(context.Background(), domain.SearchRequest{})
+	assert.NoError(t, err)
+
+	var withFamilies []garudaindonesia.FlightInfo
+	for _, f := range raw.Flights {
+		if len(f.FareFamilies) > 1 {
+			withFamilies = append(withFamilies, f)
+		}
+	}
+	if !assert.NotEmpty(t, withFamilies) {
+		return
+	}
+	f := withFamilies[0]
+	f.FareFamilies = slices.Clone(f.FareFamilies)
+	f.FareFamilies[0].Name = "Promo"
+
+	flight, err := f.ToDomainFlightInfo()
+
+	assert.NoError(t, err)
+	// The unknown family is dropped rather than offered without a brand
+	assert.Len(t, flight.FareOffers, len(f.FareFamilies)-1)
+	for _, o := range flight.FareOffers {
+		assert.NotEmpty(t, o.Brand)
+	}
+	assert.NotEmpty(t, flight.SelectedFare.Brand)
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/ratelimit"
+)
+
+// latencyTracker keeps the most recent successful latencies of a provider.
+// A nil tracker records nothing.
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	size    int
+}
+
+func newLatencyTracker(size int) *latencyTracker {
+	return &latencyTracker{size: max(size, 1)}
+}
+
+func (lt *latencyTracker) Record(d time.Duration) {
+	if lt == nil {
+		return
+	}
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	if len(lt.samples) < lt.size {
+		lt.samples = append(lt.samples, d)
+		return
+	}
+	lt.samples[lt.next] = d
+	lt.next = (lt.next + 1) % lt.size
+}
+
+// Percentile returns the p-th percentile (0-100) of the recorded latencies
+// and the number of samples it was computed from.
+func (lt *latencyTracker) Percentile(p float64) (time.Duration, int) {
+	if lt == nil {
+		return 0, 0
+	}
+	lt.mu.Lock()
+	sorted := slices.Clone(lt.samples)
+	lt.mu.Unlock()
+	if len(sorted) == 0 {
+		return 0, 0
+	}
+	slices.Sort(sorted)
+	// Nearest-rank percentile
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1], len(sorted)
+}
+
+// hedgeOutcome reports what happened to the hedge of a single attempt.
+type hedgeOutcome struct {
+	hedged  bool
+	won     bool
+	skipped bool
+}
+
+// hedgeDelay returns how long to wait for a provider before hedging.
+func (ap *AirlineProvider) hedgeDelay(lt *latencyTracker) time.Duration {
+	if d, n := lt.Percentile(ap.hedge.Percentile); n >= ap.hedge.MinSamples && n > 0 {
+		return d
+	}
+	return time.Duration(ap.hedge.DelayMs) * time.Millisecond
+}
+
+// searchHedged runs one attempt against airline. With hedging enabled, a
+// second request is sent once the hedge delay has passed and rl still has
+// quota left; the first success wins and the other request is cancelled.
+func (ap *AirlineProvider) searchHedged(ctx context.Context, airline AirlineInterface, rl ratelimit.Limiter, lt *latencyTracker, input domain.SearchRequest) ([]domain.FlightInfo, hedgeOutcome, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type attempt struct {
+		flights []domain.FlightInfo
+		err     error
+		hedge   bool
+		start   time.Time
+	}
+	results := make(chan attempt, 2)
+	launch := func(hedge bool) {
+		start := ap.clock.Now()
+		go func() {
+			flights, err := airline.SearchFlights(ctx, input)
+			results <- attempt{flights: flights, err: err, hedge: hedge, start: start}
+		}()
+	}
+
+	var (
+		outcome  hedgeOutcome
+		hedgeAt  <-chan time.Time
+		inflight = 1
+		lastErr  error
+	)
+	launch(false)
+	if ap.hedge.Enabled {
+		hedgeAt = ap.clock.After(ap.hedgeDelay(lt))
+	}
+
+	for inflight > 0 {
+		select {
+		case <-hedgeAt:
+			hedgeAt = nil
+			// The hedge goes through the provider's rate limiter as well, only
+			// send it when it would not be rejected
+			if rl != nil && rl.Tokens() < 1 {
+				outcome.skipped = true
+				continue
+			}
+			outcome.hedged = true
+			inflight++
+			launch(true)
+		case res := <-results:
+			inflight--
+			if res.err != nil {
+				lastErr = res.err
+				continue
+			}
+			lt.Record(ap.clock.Since(res.start))
+			outcome.won = res.hedge
+			return res.flights, outcome, nil
+		}
+	}
+	return nil, outcome, lastErr
+}
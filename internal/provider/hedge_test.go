@@ -0,0 +1,178 @@
+package provider
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubLimiter struct {
+	tokens float64
+}
+
+func (l *stubLimiter) Allow() bool     { return l.tokens >= 1 }
+func (l *stubLimiter) Tokens() float64 { return l.tokens }
+
+// slowThenFast hangs on its first call until cancelled and answers every
+// later call immediately. started is closed once the first call is running.
+func slowThenFast(started, cancelled chan<- struct{}) AirlineInterface {
+	var calls atomic.Int32
+	return AirlineFunc(func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}
+		return []domain.FlightInfo{{ID: "hedged"}}, nil
+	})
+}
+
+func TestLatencyTracker_Percentile(t *testing.T) {
+	lt := newLatencyTracker(100)
+	for i := 100; i >= 1; i-- {
+		lt.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	p95, n := lt.Percentile(95)
+	assert.Equal(t, 95*time.Millisecond, p95)
+	assert.Equal(t, 100, n)
+	p50, _ := lt.Percentile(50)
+	assert.Equal(t, 50*time.Millisecond, p50)
+
+	// The window keeps the most recent samples only
+	for range 100 {
+		lt.Record(time.Second)
+	}
+	p50, _ = lt.Percentile(50)
+	assert.Equal(t, time.Second, p50)
+
+	var nilTracker *latencyTracker
+	nilTracker.Record(time.Second)
+	_, n = nilTracker.Percentile(95)
+	assert.Equal(t, 0, n)
+}
+
+func TestAirlineProvider_SearchHedged(t *testing.T) {
+	hedge := config.HedgeConfig{Enabled: true, Percentile: 95, MinSamples: 1, DelayMs: 100, WindowSize: 10}
+
+	t.Run("Hedge_WinsAndCancelsPrimary", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		ap := &AirlineProvider{clock: clk, hedge: hedge}
+		started, cancelled := make(chan struct{}), make(chan struct{})
+
+		type result struct {
+			flights []domain.FlightInfo
+			outcome hedgeOutcome
+			err     error
+		}
+		done := make(chan result, 1)
+		go func() {
+			flights, outcome, err := ap.searchHedged(context.Background(), slowThenFast(started, cancelled), &stubLimiter{tokens: 10}, nil, domain.SearchRequest{})
+			done <- result{flights, outcome, err}
+		}()
+		<-started
+		clk.BlockUntil(1)
+		clk.Advance(100 * time.Millisecond)
+
+		res := <-done
+		assert.NoError(t, res.err)
+		assert.Equal(t, "hedged", res.flights[0].ID)
+		assert.Equal(t, hedgeOutcome{hedged: true, won: true}, res.outcome)
+		<-cancelled
+	})
+
+	t.Run("FastPrimary_NotHedged", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		ap := &AirlineProvider{clock: clk, hedge: hedge}
+		lt := newLatencyTracker(10)
+
+		flights, outcome, err := ap.searchHedged(context.Background(), &MockAirline{Flights: []domain.FlightInfo{{ID: "f1"}}}, nil, lt, domain.SearchRequest{})
+
+		assert.NoError(t, err)
+		assert.Len(t, flights, 1)
+		assert.Equal(t, hedgeOutcome{}, outcome)
+		_, n := lt.Percentile(95)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("Hedge_SkippedWithoutQuota", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		ap := &AirlineProvider{clock: clk, hedge: hedge}
+		ctx, cancel := context.WithCancel(context.Background())
+		started, cancelled := make(chan struct{}), make(chan struct{})
+
+		done := make(chan hedgeOutcome, 1)
+		go func() {
+			_, outcome, _ := ap.searchHedged(ctx, slowThenFast(started, cancelled), &stubLimiter{tokens: 0.5}, nil, domain.SearchRequest{})
+			done <- outcome
+		}()
+		<-started
+		clk.BlockUntil(1)
+		clk.Advance(100 * time.Millisecond)
+		// Only the primary is in flight, it ends with the caller's context
+		cancel()
+
+		assert.Equal(t, hedgeOutcome{skipped: true}, <-done)
+	})
+
+	t.Run("Delay_FollowsObservedPercentile", func(t *testing.T) {
+		ap := &AirlineProvider{hedge: config.HedgeConfig{Enabled: true, Percentile: 50, MinSamples: 3, DelayMs: 100}}
+		lt := newLatencyTracker(10)
+
+		lt.Record(10 * time.Millisecond)
+		lt.Record(20 * time.Millisecond)
+		assert.Equal(t, 100*time.Millisecond, ap.hedgeDelay(lt))
+
+		lt.Record(30 * time.Millisecond)
+		assert.Equal(t, 20*time.Millisecond, ap.hedgeDelay(lt))
+	})
+}
+
+func TestAirlineProvider_SearchFlights_HedgeMetadata(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	fast := &MockAirline{Flights: []domain.FlightInfo{{ID: "f1"}}}
+	started := make(chan struct{})
+	// Fast providers have a history of slow answers so only AirAsia hedges
+	latencies := map[consts.ProviderKey]*latencyTracker{}
+	for _, key := range []consts.ProviderKey{consts.ProviderKeyBatikAir, consts.ProviderKeyGarudaIndonesia, consts.ProviderKeyLionAir} {
+		latencies[key] = newLatencyTracker(10)
+		latencies[key].Record(time.Hour)
+	}
+	ap := &AirlineProvider{
+		clock:           clk,
+		hedge:           config.HedgeConfig{Enabled: true, Percentile: 95, MinSamples: 1, DelayMs: 100},
+		latencies:       latencies,
+		airAsia:         slowThenFast(started, make(chan struct{})),
+		batikAir:        fast,
+		garudaIndonesia: fast,
+		lionAir:         fast,
+	}
+
+	done := make(chan *domain.SearchResponse, 1)
+	go func() {
+		resp, _ := ap.SearchFlights(context.Background(), domain.SearchRequest{})
+		done <- resp
+	}()
+	// The aggregator deadline and one hedge timer per provider
+	<-started
+	clk.BlockUntil(5)
+	clk.Advance(100 * time.Millisecond)
+
+	resp := <-done
+	assert.Equal(t, 4, resp.Metadata.ProvidersSucceeded)
+	for _, meta := range resp.Metadata.Providers {
+		assert.True(t, meta.Succeeded)
+		assert.Equal(t, 1, meta.Attempts)
+		hedged := meta.Provider == string(consts.ProviderKeyAirAsia)
+		assert.Equal(t, hedged, meta.Hedged, meta.Provider)
+		assert.Equal(t, hedged, meta.HedgeWon, meta.Provider)
+	}
+}
@@ -3,14 +3,32 @@ package lionair
 import (
 	"time"
 
+	"github.com/azcov/bookcabin_test/internal/catalog"
+	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
 	"github.com/azcov/bookcabin_test/internal/util"
 	"github.com/azcov/bookcabin_test/pkg/logger"
-	"github.com/leekchan/accounting"
 )
 
+const ProviderName = "Lion Air"
+
 var (
 	TimeFormat = "2006-01-02T15:04:05"
+
+	// fareTypeToCabinClass overrides the conventional RBD mapping: Lion Air
+	// has no first or premium economy cabin.
+	fareTypeToCabinClass = map[string]consts.CabinClass{
+		"F": consts.CabinClassBusiness,
+		"A": consts.CabinClassBusiness,
+		"P": consts.CabinClassBusiness,
+		"W": consts.CabinClassEconomy,
+		"E": consts.CabinClassEconomy,
+	}
+
+	serviceToAmenity = map[string]consts.AmenityType{
+		"wifi_available": consts.AmenityTypeWifi,
+		"meals_included": consts.AmenityTypeMeal,
+	}
 )
 
 type Carrier struct {
@@ -47,6 +65,17 @@ type BaggageAllowance struct {
 	Hold  string `json:"hold"`
 }
 
+// toDomain normalizes weight allowances such as "7 kg". An allowance without
+// a weight is not included in the fare.
+func (b BaggageAllowance) toDomain() domain.BaggageInfo {
+	cabinKg := util.ParseWeightKg(b.Cabin)
+	holdKg := util.ParseWeightKg(b.Hold)
+	return domain.BaggageInfo{
+		CarryOn: domain.NewBaggageAllowance(1, cabinKg, cabinKg > 0, nil),
+		Checked: domain.NewBaggageAllowance(0, holdKg, holdKg > 0, nil),
+	}
+}
+
 type Services struct {
 	WifiAvailable    bool             `json:"wifi_available"`
 	MealsIncluded    bool             `json:"meals_included"`
@@ -97,81 +126,54 @@ func (f *FlightInfo) ToDomainFlightInfo() (domain.FlightInfo, error) {
 		return domain.FlightInfo{}, err
 	}
 
-	departTs := departTime.Unix()
-	arriveTs := arriveTime.Unix()
-
-	// Format duration
-	formattedDuration := util.FormatDurationMinute(f.FlightTime)
-
-	ac := accounting.Accounting{Symbol: f.Pricing.Currency, Precision: 0, Format: "%s %v", Thousand: ".", Decimal: ","}
-	formattedPrice := ac.FormatMoney(f.Pricing.Total)
-
 	result := domain.FlightInfo{
 		ID:       f.ID + "_" + f.Carrier.Name,
 		Provider: f.Carrier.Name,
 
-		Airline: domain.AirlineInfo{
-			Name: f.Carrier.Name,
-			Code: f.Carrier.Iata,
-		},
+		Airline: catalog.AirlineInfo(f.Carrier.Iata, f.Carrier.Name),
 
 		FlightNumber: f.ID,
 
-		Departure: domain.AirportInfo{
-			Airport:   f.Route.From.Code,
-			City:      f.Route.From.City,
-			Datetime:  departTime,
-			Timestamp: departTs,
-		},
-
-		Arrival: domain.AirportInfo{
-			Airport:   f.Route.To.Code,
-			City:      f.Route.To.City,
-			Datetime:  arriveTime,
-			Timestamp: arriveTs,
-		},
-
-		Duration: domain.DurationInfo{
-			TotalMinutes: f.FlightTime,
-			Formatted:    formattedDuration,
-		},
+		Departure: domain.NewAirportInfo(f.Route.From.Code, f.Route.From.City, "", departTime),
+
+		Arrival: domain.NewAirportInfo(f.Route.To.Code, f.Route.To.City, "", arriveTime),
+
+		Duration: domain.NewDurationInfo(departTime, arriveTime),
 
 		Stops: f.StopCount,
 
-		Price: domain.PriceInfo{
-			Amount:   f.Pricing.Total,
-			Currency: f.Pricing.Currency,
-			Display:  formattedPrice,
-		},
+		Price: domain.NewPriceInfo(f.Pricing.Currency, f.Pricing.Total, 0, 0, 0),
 
 		AvailableSeats: f.SeatsLeft,
-		CabinClass:     f.Pricing.FareType,
+		CabinClass:     consts.ResolveCabinClass(fareTypeToCabinClass, f.Pricing.FareType),
 
-		Aircraft: &domain.AircraftInfo{
-			Model: f.PlaneType,
-			Code:  "",
-		},
+		Aircraft: catalog.AircraftInfo(f.PlaneType, f.Carrier.Iata),
 
 		Amenities: []domain.AmenityInfo{},
 
-		Baggage: domain.BaggageInfo{
-			CarryOn: f.Services.BaggageAllowance.Cabin,
-			Checked: f.Services.BaggageAllowance.Hold,
-		},
+		Layovers: []domain.Layover{},
+
+		Baggage: f.Services.BaggageAllowance.toDomain(),
+	}
+
+	for _, l := range f.Layovers {
+		result.Layovers = append(result.Layovers, domain.NewLayover(l.Airport, l.DurationMinutes))
 	}
+	result.Segments = domain.NewSegmentsFromLayovers(f.ID, result.Departure, result.Arrival, result.Duration, result.Aircraft, result.Layovers)
 
 	if f.Services.WifiAvailable {
-		result.Amenities = append(result.Amenities, domain.AmenityInfo{
-			Type:        "WiFi",
-			Description: "WiFi Availabe",
-		})
+		result.Amenities = append(result.Amenities, domain.NewAmenityInfo(serviceToAmenity, "wifi_available"))
 	}
 	if f.Services.MealsIncluded {
-		result.Amenities = append(result.Amenities, domain.AmenityInfo{
-			Type:        "Meal",
-			Description: "Meal Included",
-		})
+		result.Amenities = append(result.Amenities, domain.NewAmenityInfo(serviceToAmenity, "meals_included"))
 	}
+	result.FareOffers = []domain.FareOffer{{
+		Brand:   consts.FareBrandValue,
+		Price:   result.Price,
+		Baggage: result.Baggage,
+	}}
+	result.SelectFareOffer("")
+
 	result.CalculateBestValueScore()
 	return result, nil
 }
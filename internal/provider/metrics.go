@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"github.com/azcov/bookcabin_test/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	providerCalls = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_calls_total",
+		Help: "Provider searches by provider and outcome, retries included.",
+	}, []string{"provider", "outcome"})
+	providerCallDuration = metrics.Factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "provider_call_duration_seconds",
+		Help:    "Provider search latency by provider and outcome, retries included.",
+		Buckets: []float64{.025, .05, .1, .25, .5, 1, 2, 5},
+	}, []string{"provider", "outcome"})
+	providerErrors = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_errors_total",
+		Help: "Failed provider attempts by provider and error code.",
+	}, []string{"provider", "code"})
+	providerRetries = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_retries_total",
+		Help: "Provider attempts retried after a failed attempt.",
+	}, []string{"provider"})
+	providerRateLimited = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_rate_limited_total",
+		Help: "Provider requests rejected by the local rate limiter.",
+	}, []string{"provider"})
+)
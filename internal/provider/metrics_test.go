@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/errors"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/azcov/bookcabin_test/pkg/ratelimit"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAirlineProvider_SearchProviders_Metrics(t *testing.T) {
+	// Metrics are global, so only their increase is asserted
+	calls := func(provider, outcome string) float64 {
+		return testutil.ToFloat64(providerCalls.WithLabelValues(provider, outcome))
+	}
+	lionErrors := func() float64 {
+		return testutil.ToFloat64(providerErrors.WithLabelValues("lion", "rate_limit_exceeded"))
+	}
+	lionRetries := func() float64 { return testutil.ToFloat64(providerRetries.WithLabelValues("lion")) }
+	lionLimited := func() float64 { return testutil.ToFloat64(providerRateLimited.WithLabelValues("lion")) }
+
+	garudaOK, lionFailed := calls("garuda", "success"), calls("lion", "error")
+	errs, retries, limited := lionErrors(), lionRetries(), lionLimited()
+
+	ap := &AirlineProvider{
+		clock:           clock.New(),
+		garudaIndonesia: &MockAirline{Flights: []domain.FlightInfo{{ID: "f1"}}},
+		lionAir:         Chain(&MockAirline{}, WithRateLimit(consts.ProviderKeyLionAir, ratelimit.NewWithDuration(1, time.Hour), errors.ErrLionAirRateLimitExceeded)),
+	}
+	// Exhaust lion's quota
+	_, _ = ap.lionAir.SearchFlights(context.Background(), domain.SearchRequest{})
+
+	_, err := ap.SearchProviders(context.Background(), domain.SearchRequest{}, []consts.ProviderKey{consts.ProviderKeyGarudaIndonesia, consts.ProviderKeyLionAir})
+
+	assert.NoError(t, err)
+	assert.Equal(t, garudaOK+1, calls("garuda", "success"))
+	assert.Equal(t, lionFailed+1, calls("lion", "error"))
+	// Three attempts, each rejected by the rate limiter
+	assert.Equal(t, errs+3, lionErrors())
+	assert.Equal(t, retries+2, lionRetries())
+	assert.Equal(t, limited+3, lionLimited())
+}
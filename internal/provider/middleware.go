@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/azcov/bookcabin_test/internal/chaos"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/azcov/bookcabin_test/pkg/ratelimit"
+)
+
+// AirlineFunc adapts a function to AirlineInterface.
+type AirlineFunc func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error)
+
+func (fn AirlineFunc) SearchFlights(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+	return fn(ctx, input)
+}
+
+// Middleware wraps an AirlineInterface with cross-cutting behavior.
+type Middleware func(next AirlineInterface) AirlineInterface
+
+// Chain wraps p with middlewares. The first middleware is the outermost, so
+// it runs first on every search.
+func Chain(p AirlineInterface, middlewares ...Middleware) AirlineInterface {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		p = middlewares[i](p)
+	}
+	return p
+}
+
+// WithRateLimit rejects searches to provider key with errLimited once rl is
+// exhausted.
+func WithRateLimit(key consts.ProviderKey, rl ratelimit.Limiter, errLimited error) Middleware {
+	return func(next AirlineInterface) AirlineInterface {
+		return AirlineFunc(func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+			if !rl.Allow() {
+				providerRateLimited.WithLabelValues(string(key)).Inc()
+				return nil, errLimited
+			}
+			return next.SearchFlights(ctx, input)
+		})
+	}
+}
+
+// WithChaos injects the latency and faults planned by injector for the
+// provider, waiting on clk. errs maps each chaos error type to the
+// provider's error; a nil injector disables injection.
+func WithChaos(injector *chaos.Injector, clk clock.Clock, key consts.ProviderKey, errs map[chaos.ErrorType]error) Middleware {
+	return func(next AirlineInterface) AirlineInterface {
+		if injector == nil {
+			return next
+		}
+		return AirlineFunc(func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+			plan := injector.Plan(key)
+			start := clk.Now()
+
+			var (
+				flights []domain.FlightInfo
+				err     error
+			)
+			switch plan.Fault {
+			case chaos.FaultError:
+				err = errs[plan.ErrorType]
+			case chaos.FaultTimeout:
+				// The provider never answers, the caller's deadline ends the call
+				<-ctx.Done()
+				return nil, ctx.Err()
+			default:
+				flights, err = next.SearchFlights(chaos.WithFault(ctx, plan.Fault), input)
+			}
+
+			// Time spent in next counts toward the simulated latency
+			if werr := clock.Sleep(ctx, clk, plan.Latency-clk.Since(start)); werr != nil {
+				return nil, werr
+			}
+			return flights, err
+		})
+	}
+}
+
+// ChaosPayloadHook corrupts the payload of calls planned as malformed.
+func ChaosPayloadHook(ctx context.Context, data []byte) []byte {
+	if chaos.FaultFromContext(ctx) == chaos.FaultMalformed {
+		return chaos.Corrupt(data)
+	}
+	return data
+}
@@ -2,26 +2,54 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/azcov/bookcabin_test/internal/chaos"
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/azcov/bookcabin_test/pkg/errorz"
 	"github.com/azcov/bookcabin_test/pkg/logger"
+	"github.com/azcov/bookcabin_test/pkg/ratelimit"
+	"github.com/azcov/bookcabin_test/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type AirlineProvider struct {
+	clock           clock.Clock
+	hedge           config.HedgeConfig
+	limiters        map[consts.ProviderKey]ratelimit.Limiter
+	latencies       map[consts.ProviderKey]*latencyTracker
 	airAsia         AirlineInterface
 	batikAir        AirlineInterface
 	garudaIndonesia AirlineInterface
 	lionAir         AirlineInterface
 }
 
-func NewAirlineProvider() *AirlineProvider {
-	airAsia := NewAirAsiaProvider("./internal/provider/mock")
-	batikAir := NewBatikAirProvider("./internal/provider/mock")
-	garudaIndonesia := NewGarudaIndonesiaProvider("./internal/provider/mock")
-	lionAir := NewLionAirProvider("./internal/provider/mock")
+func NewAirlineProvider(injector *chaos.Injector, clk clock.Clock, hedge config.HedgeConfig) *AirlineProvider {
+	// Limiters are shared with the aggregator so hedges respect the quotas
+	limiters := make(map[consts.ProviderKey]ratelimit.Limiter, len(consts.ProviderKeys))
+	latencies := make(map[consts.ProviderKey]*latencyTracker, len(consts.ProviderKeys))
+	for _, key := range consts.ProviderKeys {
+		limiters[key] = ratelimit.NewWithDuration(100, time.Second)
+		latencies[key] = newLatencyTracker(hedge.WindowSize)
+	}
+
+	airAsia := NewAirAsiaProvider("./internal/provider/mock", limiters[consts.ProviderKeyAirAsia], injector, clk)
+	batikAir := NewBatikAirProvider("./internal/provider/mock", limiters[consts.ProviderKeyBatikAir], injector, clk)
+	garudaIndonesia := NewGarudaIndonesiaProvider("./internal/provider/mock", limiters[consts.ProviderKeyGarudaIndonesia], injector, clk)
+	lionAir := NewLionAirProvider("./internal/provider/mock", limiters[consts.ProviderKeyLionAir], injector, clk)
 	return &AirlineProvider{
+		clock:           clk,
+		hedge:           hedge,
+		limiters:        limiters,
+		latencies:       latencies,
 		airAsia:         airAsia,
 		batikAir:        batikAir,
 		garudaIndonesia: garudaIndonesia,
@@ -29,36 +57,50 @@ func NewAirlineProvider() *AirlineProvider {
 	}
 }
 
+func (ap *AirlineProvider) Quota(key consts.ProviderKey) float64 {
+	rl, ok := ap.limiters[key]
+	if !ok {
+		return 0
+	}
+	return rl.Tokens()
+}
+
 func (ap *AirlineProvider) SearchFlights(ctx context.Context, input domain.SearchRequest) (*domain.SearchResponse, error) {
+	return ap.SearchProviders(ctx, input, consts.ProviderKeys)
+}
+
+func (ap *AirlineProvider) SearchProviders(ctx context.Context, input domain.SearchRequest, keys []consts.ProviderKey) (*domain.SearchResponse, error) {
 	// Implementation for searching flights from this specific airline provider
 	MAX_RETRY := 3
 	TIMEOUT := 2 * time.Second
 
-	ctx, cancel := context.WithTimeout(ctx, TIMEOUT)
+	// A fast search only waits as long as the client asked for
+	if input.Mode == consts.SearchModeFast && input.MaxWaitMs > 0 {
+		TIMEOUT = min(TIMEOUT, time.Duration(input.MaxWaitMs)*time.Millisecond)
+	}
+
+	ctx, span := tracing.Start(ctx, "AirlineProvider.SearchProviders")
+	defer span.End()
+
+	ctx, cancel := ap.clock.WithTimeout(ctx, TIMEOUT)
 	defer cancel()
 
 	type result struct {
-		provider string
+		provider consts.ProviderKey
 		flights  []domain.FlightInfo
 		err      error
+		meta     domain.ProviderMetadata
 	}
 
-	providers := []struct {
-		name string
-		fn   func() ([]domain.FlightInfo, error)
-	}{
-		{"airasia", func() ([]domain.FlightInfo, error) {
-			return ap.airAsia.SearchFlights(ctx, input)
-		}},
-		{"batik", func() ([]domain.FlightInfo, error) {
-			return ap.batikAir.SearchFlights(ctx, input)
-		}},
-		{"garuda", func() ([]domain.FlightInfo, error) {
-			return ap.garudaIndonesia.SearchFlights(ctx, input)
-		}},
-		{"lion", func() ([]domain.FlightInfo, error) {
-			return ap.lionAir.SearchFlights(ctx, input)
-		}},
+	type namedAirline struct {
+		name    consts.ProviderKey
+		airline AirlineInterface
+	}
+	providers := make([]namedAirline, 0, len(keys))
+	for _, key := range keys {
+		if airline := ap.airline(key); airline != nil {
+			providers = append(providers, namedAirline{key, airline})
+		}
 	}
 
 	var wg sync.WaitGroup
@@ -71,23 +113,43 @@ func (ap *AirlineProvider) SearchFlights(ctx context.Context, input domain.Searc
 
 		go func(i int) {
 			defer wg.Done()
+			ctx := logger.WithContext(ctx, "provider", provider.name)
 
 			var (
 				flights []domain.FlightInfo
 				err     error
+				meta    = domain.ProviderMetadata{Provider: string(provider.name)}
+				start   = ap.clock.Now()
 			)
 			for range MAX_RETRY {
 				if ctx.Err() != nil {
 					err = ctx.Err()
 					break
 				}
-				flights, err = provider.fn()
+				var hedge hedgeOutcome
+				meta.Attempts++
+				if meta.Attempts > 1 {
+					providerRetries.WithLabelValues(string(provider.name)).Inc()
+				}
+				flights, hedge, err = ap.searchAttempt(ctx, provider.name, meta.Attempts, provider.airline, input)
+				meta.Hedged = meta.Hedged || hedge.hedged
+				meta.HedgeWon = meta.HedgeWon || hedge.won
+				meta.HedgeSkipped = meta.HedgeSkipped || hedge.skipped
 				// retry if error
 				if err == nil {
 					break
 				}
+				providerErrors.WithLabelValues(string(provider.name), errorCode(err)).Inc()
+			}
+			elapsed := ap.clock.Since(start)
+			meta.LatencyMs = int(elapsed.Milliseconds())
+			outcome := "success"
+			if err != nil {
+				outcome = "error"
 			}
-			ch <- result{provider: provider.name, flights: flights, err: err}
+			providerCalls.WithLabelValues(string(provider.name), outcome).Inc()
+			providerCallDuration.WithLabelValues(string(provider.name), outcome).Observe(elapsed.Seconds())
+			ch <- result{provider: provider.name, flights: flights, err: err, meta: meta}
 		}(idx)
 	}
 
@@ -102,17 +164,85 @@ func (ap *AirlineProvider) SearchFlights(ctx context.Context, input domain.Searc
 		// logger.InfoContext(ctx, "Provider Result: ", "flights", res.flights, "err", res.err)
 		resp.Metadata.ProvidersQueried++
 		if res.err != nil {
-			logger.ErrorContext(ctx, "Provider failed", "err", res.err)
+			logger.ErrorContext(ctx, "Provider failed", "provider", res.provider, "err", res.err)
 			resp.Metadata.ProvidersFailed++
+			resp.Metadata.DegradedProviders = append(resp.Metadata.DegradedProviders, string(res.provider))
+			res.meta.ErrorCode = errorCode(res.err)
+			resp.Metadata.Providers = append(resp.Metadata.Providers, res.meta)
 			continue
 		}
 		logger.InfoContext(ctx, "Provider succeeded", "provider", res.provider, "len_flights", len(res.flights))
+		for i := range res.flights {
+			res.flights[i].ProviderKey = res.provider
+		}
 		resp.Metadata.TotalResults += len(res.flights)
 		resp.Flights = append(resp.Flights, res.flights...)
 		resp.Metadata.ProvidersSucceeded++
+		res.meta.Succeeded = true
+		res.meta.Flights = len(res.flights)
+		resp.Metadata.Providers = append(resp.Metadata.Providers, res.meta)
 	}
+	slices.SortFunc(resp.Metadata.Providers, func(a, b domain.ProviderMetadata) int {
+		return strings.Compare(a.Provider, b.Provider)
+	})
+	slices.Sort(resp.Metadata.DegradedProviders)
+	resp.Metadata.Complete = !resp.Metadata.Degraded()
 
 	logger.InfoContext(ctx, "Total results", "total", len(resp.Flights))
+	span.SetAttributes(
+		attribute.Int("providers.queried", resp.Metadata.ProvidersQueried),
+		attribute.Int("providers.failed", resp.Metadata.ProvidersFailed),
+		attribute.Int("flights", len(resp.Flights)),
+	)
 
 	return resp, nil
 }
+
+// searchAttempt runs the attempt-th search of provider key in its own span.
+func (ap *AirlineProvider) searchAttempt(ctx context.Context, key consts.ProviderKey, attempt int, airline AirlineInterface, input domain.SearchRequest) ([]domain.FlightInfo, hedgeOutcome, error) {
+	ctx, span := tracing.Start(ctx, "provider.attempt", trace.WithAttributes(
+		attribute.String("provider", string(key)),
+		attribute.Int("attempt", attempt),
+	))
+	flights, hedge, err := ap.searchHedged(ctx, airline, ap.limiters[key], ap.latencies[key], input)
+	status := "ok"
+	if err != nil {
+		status = errorCode(err)
+	}
+	span.SetAttributes(
+		attribute.String("status", status),
+		attribute.Int("flights", len(flights)),
+		attribute.Bool("hedged", hedge.hedged),
+	)
+	tracing.End(span, err)
+	return flights, hedge, err
+}
+
+func (ap *AirlineProvider) airline(key consts.ProviderKey) AirlineInterface {
+	switch key {
+	case consts.ProviderKeyAirAsia:
+		return ap.airAsia
+	case consts.ProviderKeyBatikAir:
+		return ap.batikAir
+	case consts.ProviderKeyGarudaIndonesia:
+		return ap.garudaIndonesia
+	case consts.ProviderKeyLionAir:
+		return ap.lionAir
+	}
+	return nil
+}
+
+// errorCode returns the ErrCode of provider errors, and a code for the
+// context errors that end an attempt.
+func errorCode(err error) string {
+	var we *errorz.WrappedError
+	switch {
+	case errors.As(err, &we):
+		return we.ErrCode
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "unknown"
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 
+	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
 )
 
@@ -12,4 +13,10 @@ type AirlineInterface interface {
 
 type AirlineAggregator interface {
 	SearchFlights(ctx context.Context, input domain.SearchRequest) (*domain.SearchResponse, error)
+	// SearchProviders searches only the given providers, e.g. to complete a
+	// degraded result.
+	SearchProviders(ctx context.Context, input domain.SearchRequest, keys []consts.ProviderKey) (*domain.SearchResponse, error)
+	// Quota reports the requests the provider's rate limit allows right now,
+	// so that background work can leave them to client searches.
+	Quota(key consts.ProviderKey) float64
 }
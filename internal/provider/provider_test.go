@@ -3,10 +3,13 @@ package provider
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/clock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -87,6 +90,7 @@ func TestAirlineProvider_SearchFlights(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 
 			ap := &AirlineProvider{
+				clock:           clock.New(),
 				airAsia:         tt.mocks["airasia"],
 				batikAir:        tt.mocks["batik"],
 				garudaIndonesia: tt.mocks["garuda"],
@@ -104,3 +108,100 @@ func TestAirlineProvider_SearchFlights(t *testing.T) {
 		})
 	}
 }
+
+func TestAirlineProvider_SearchFlights_Timeout(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	hanging := AirlineFunc(func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	var answered sync.WaitGroup
+	answered.Add(2)
+	answering := func(id string) AirlineInterface {
+		return AirlineFunc(func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+			defer answered.Done()
+			return []domain.FlightInfo{{ID: id}}, nil
+		})
+	}
+	ap := &AirlineProvider{
+		clock:           clk,
+		airAsia:         answering("f1"),
+		batikAir:        hanging,
+		garudaIndonesia: answering("f3"),
+		lionAir:         hanging,
+	}
+
+	done := make(chan *domain.SearchResponse, 1)
+	go func() {
+		resp, err := ap.SearchFlights(context.Background(), domain.SearchRequest{})
+		assert.NoError(t, err)
+		done <- resp
+	}()
+	// The aggregator deadline is the only waiter on the clock
+	clk.BlockUntil(1)
+	answered.Wait()
+	clk.Advance(2 * time.Second)
+
+	resp := <-done
+	assert.Equal(t, 2, len(resp.Flights))
+	assert.Equal(t, 2, resp.Metadata.ProvidersSucceeded)
+	assert.Equal(t, 2, resp.Metadata.ProvidersFailed)
+	assert.False(t, resp.Metadata.Complete)
+	assert.Equal(t, []string{"batik", "lion"}, resp.Metadata.DegradedProviders)
+}
+
+func TestAirlineProvider_SearchFlights_FastMode(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	hanging := AirlineFunc(func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	var answered sync.WaitGroup
+	answered.Add(3)
+	answering := AirlineFunc(func(ctx context.Context, input domain.SearchRequest) ([]domain.FlightInfo, error) {
+		defer answered.Done()
+		return []domain.FlightInfo{{ID: "f1"}}, nil
+	})
+	ap := &AirlineProvider{
+		clock:           clk,
+		airAsia:         answering,
+		batikAir:        answering,
+		garudaIndonesia: answering,
+		lionAir:         hanging,
+	}
+
+	done := make(chan *domain.SearchResponse, 1)
+	go func() {
+		resp, _ := ap.SearchFlights(context.Background(), domain.SearchRequest{Mode: consts.SearchModeFast, MaxWaitMs: 300})
+		done <- resp
+	}()
+	clk.BlockUntil(1)
+	answered.Wait()
+	// The fast deadline replaces the aggregator timeout
+	clk.Advance(300 * time.Millisecond)
+
+	resp := <-done
+	assert.Equal(t, 3, len(resp.Flights))
+	assert.False(t, resp.Metadata.Complete)
+	assert.Equal(t, []string{"lion"}, resp.Metadata.DegradedProviders)
+	assert.Equal(t, "timeout", resp.Metadata.Providers[3].ErrorCode)
+}
+
+func TestAirlineProvider_SearchProviders(t *testing.T) {
+	ap := &AirlineProvider{
+		clock:           clock.New(),
+		airAsia:         &MockAirline{Err: errors.New("not queried")},
+		batikAir:        &MockAirline{Err: errors.New("not queried")},
+		garudaIndonesia: &MockAirline{Err: errors.New("not queried")},
+		lionAir:         &MockAirline{Flights: []domain.FlightInfo{{ID: "f4"}}},
+	}
+
+	resp, err := ap.SearchProviders(context.Background(), domain.SearchRequest{}, []consts.ProviderKey{consts.ProviderKeyLionAir})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Metadata.ProvidersQueried)
+	assert.Equal(t, 1, resp.Metadata.ProvidersSucceeded)
+	assert.True(t, resp.Metadata.Complete)
+	assert.Equal(t, "f4", resp.Flights[0].ID)
+	assert.Equal(t, consts.ProviderKeyLionAir, resp.Flights[0].ProviderKey)
+}
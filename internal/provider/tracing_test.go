@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/errors"
+	"github.com/azcov/bookcabin_test/internal/testutil"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/azcov/bookcabin_test/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestAirlineProvider_SearchProviders_Spans(t *testing.T) {
+	sr, restore := testutil.NewRecorder()
+	defer restore()
+
+	ap := &AirlineProvider{
+		clock:           clock.New(),
+		garudaIndonesia: &MockAirline{Flights: []domain.FlightInfo{{ID: "f1"}, {ID: "f2"}}},
+		lionAir:         &MockAirline{Err: errors.ErrLionAirRateLimitExceeded},
+	}
+
+	_, err := ap.SearchProviders(context.Background(), domain.SearchRequest{}, []consts.ProviderKey{consts.ProviderKeyGarudaIndonesia, consts.ProviderKeyLionAir})
+	assert.NoError(t, err)
+
+	fanOut := testutil.Ended(sr, "AirlineProvider.SearchProviders")
+	if assert.Len(t, fanOut, 1) {
+		assert.Contains(t, fanOut[0].Attributes(), attribute.Int("providers.failed", 1))
+	}
+	attempts := testutil.Ended(sr, "provider.attempt")
+	assert.Len(t, attempts, 4)
+
+	var lionAttempts []int64
+	for _, span := range attempts {
+		assert.Equal(t, fanOut[0].SpanContext().SpanID(), span.Parent().SpanID())
+		attrs := attribute.NewSet(span.Attributes()...)
+		provider, _ := attrs.Value("provider")
+		status, _ := attrs.Value("status")
+		flights, _ := attrs.Value("flights")
+		attempt, _ := attrs.Value("attempt")
+		switch provider.AsString() {
+		case "garuda":
+			assert.Equal(t, "ok", status.AsString())
+			assert.Equal(t, int64(2), flights.AsInt64())
+			assert.Equal(t, codes.Unset, span.Status().Code)
+		case "lion":
+			assert.Equal(t, "rate_limit_exceeded", status.AsString())
+			assert.Equal(t, codes.Error, span.Status().Code)
+			lionAttempts = append(lionAttempts, attempt.AsInt64())
+		}
+	}
+	assert.ElementsMatch(t, []int64{1, 2, 3}, lionAttempts)
+}
+
+func TestHTTPSource_PropagatesTraceContext(t *testing.T) {
+	sr, restore := testutil.NewRecorder()
+	defer restore()
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`{"OK":true,"Flights":[{"id":"f1"}]}`))
+	}))
+	defer srv.Close()
+
+	ctx, span := tracing.Start(context.Background(), "provider.attempt")
+	raw, err := NewHTTPSource[*stubResponse](srv.Client(), srv.URL).Fetch(ctx, domain.SearchRequest{})
+	span.End()
+
+	assert.NoError(t, err)
+	assert.True(t, raw.OK)
+	assert.Len(t, raw.Flights, 1)
+	calls := testutil.Ended(sr, "provider.http")
+	if assert.Len(t, calls, 1) {
+		sc := calls[0].SpanContext()
+		assert.Equal(t, "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01", traceparent)
+		assert.Equal(t, span.SpanContext().SpanID(), calls[0].Parent().SpanID())
+	}
+
+	t.Run("ErrorStatus", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer srv.Close()
+
+		_, err := NewHTTPSource[*stubResponse](srv.Client(), srv.URL).Fetch(context.Background(), domain.SearchRequest{})
+		assert.ErrorContains(t, err, "502")
+	})
+}
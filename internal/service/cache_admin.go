@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/errors"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/azcov/bookcabin_test/pkg/logger"
+)
+
+type CacheAdminInterface interface {
+	ListCacheKeys(ctx context.Context, prefix string) ([]string, error)
+	GetCacheEntry(ctx context.Context, key string) (domain.CacheEntryInfo, error)
+	PurgeCache(ctx context.Context, req domain.CachePurgeRequest) (domain.CachePurgeResponse, error)
+	GetCacheStats(ctx context.Context) (domain.CacheStats, error)
+}
+
+type cacheAdminService struct {
+	cache  cache.Cache
+	warmup WarmupInterface
+	clock  clock.Clock
+}
+
+// NewCacheAdminService administers the search cache c, which must be the one
+// the flight service uses, and reports the stats of warmup if not nil.
+func NewCacheAdminService(c cache.Cache, warmup WarmupInterface, clk clock.Clock) CacheAdminInterface {
+	return &cacheAdminService{cache: c, warmup: warmup, clock: clk}
+}
+
+func (cs *cacheAdminService) ListCacheKeys(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := cs.cache.Keys(prefix)
+	if keys == nil {
+		keys = []string{}
+	}
+	return keys, err
+}
+
+func (cs *cacheAdminService) GetCacheEntry(ctx context.Context, key string) (domain.CacheEntryInfo, error) {
+	entry, err := cs.cache.Peek(key)
+	if err == cache.ErrCacheNotFound {
+		return domain.CacheEntryInfo{}, errors.ErrCacheEntryNotFound
+	}
+	if err != nil {
+		return domain.CacheEntryInfo{}, err
+	}
+
+	now := cs.clock.Now()
+	info := domain.CacheEntryInfo{
+		Key:      key,
+		StoredAt: entry.StoredAt,
+		AgeMs:    now.Sub(entry.StoredAt).Milliseconds(),
+		TTLMs:    -1,
+		Stale:    entry.Stale(now),
+		Value:    entry.Value,
+	}
+	if !entry.StaleAt.IsZero() {
+		info.StaleAt = &entry.StaleAt
+	}
+	if !entry.ExpiresAt.IsZero() {
+		info.ExpiresAt = &entry.ExpiresAt
+		info.TTLMs = max(entry.ExpiresAt.Sub(now).Milliseconds(), 0)
+	}
+	return info, nil
+}
+
+// PurgeCache deletes the search results and raw provider results matching
+// req. A search result matches a provider when that provider answered it.
+func (cs *cacheAdminService) PurgeCache(ctx context.Context, req domain.CachePurgeRequest) (domain.CachePurgeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return domain.CachePurgeResponse{}, err
+	}
+	keys, err := cs.cache.Keys("")
+	if err != nil {
+		return domain.CachePurgeResponse{}, err
+	}
+
+	var resp domain.CachePurgeResponse
+	route := fmt.Sprintf("origin=%s;destination=%s;", req.Origin, req.Destination)
+	for _, key := range keys {
+		if req.Origin != "" && !strings.Contains(key, route) {
+			continue
+		}
+		if req.Provider != "" && !cs.holdsProvider(key, string(req.Provider)) {
+			continue
+		}
+		if err := cs.cache.Delete(key); err != nil {
+			return resp, err
+		}
+		resp.Purged++
+	}
+	logger.InfoContext(ctx, "Purged cache", "origin", req.Origin, "destination", req.Destination, "provider", req.Provider, "purged", resp.Purged)
+	return resp, nil
+}
+
+// holdsProvider reports whether the entry under key holds results of
+// provider, either as its raw results or as part of a search result.
+func (cs *cacheAdminService) holdsProvider(key, provider string) bool {
+	if strings.HasSuffix(key, ";provider="+provider) {
+		return true
+	}
+	entry, err := cs.cache.Peek(key)
+	if err != nil {
+		return false
+	}
+	resp, ok := entry.Value.(domain.SearchResponse)
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(resp.Metadata.Providers, func(pm domain.ProviderMetadata) bool {
+		return pm.Provider == provider && pm.Succeeded
+	})
+}
+
+func (cs *cacheAdminService) GetCacheStats(ctx context.Context) (domain.CacheStats, error) {
+	stats, err := cs.cache.Stats()
+	if err != nil {
+		return domain.CacheStats{}, err
+	}
+	resp := domain.CacheStats{Stats: stats}
+	if cs.warmup != nil {
+		resp.Warmup = cs.warmup.Stats()
+	}
+	return resp, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/errors"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheAdminService(t *testing.T) {
+	cgkDps := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	cgkSin := domain.SearchRequest{Origin: "CGK", Destination: "SIN", DepartureDate: "2025-12-25"}
+
+	newService := func() (CacheAdminInterface, cache.Cache, *clock.Fake) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		c := cache.NewGoCacheWithClock(cache.CacheConfig{}, clk)
+		for _, req := range []domain.SearchRequest{cgkDps, cgkSin} {
+			assert.NoError(t, c.Set(req.ToCacheKey(), *providerResults(consts.ProviderKeyGarudaIndonesia)))
+			assert.NoError(t, c.Set(req.ToProviderCacheKey(consts.ProviderKeyGarudaIndonesia), *providerResults(consts.ProviderKeyGarudaIndonesia)))
+			assert.NoError(t, c.Set(req.ToProviderCacheKey(consts.ProviderKeyLionAir), *providerResults(consts.ProviderKeyLionAir)))
+		}
+		return NewCacheAdminService(c, nil, clk), c, clk
+	}
+
+	t.Run("ListKeys_ByPrefix", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		keys, err := svc.ListCacheKeys(context.Background(), "search_flight:")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{cgkDps.ToCacheKey(), cgkSin.ToCacheKey()}, keys)
+
+		keys, err = svc.ListCacheKeys(context.Background(), "unknown:")
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+		assert.NotNil(t, keys)
+	})
+
+	t.Run("Entry_AgeAndTTL", func(t *testing.T) {
+		svc, c, clk := newService()
+		assert.NoError(t, c.SetWithStale("key", "value", time.Minute, 3*time.Minute))
+		clk.Advance(2 * time.Minute)
+
+		info, err := svc.GetCacheEntry(context.Background(), "key")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2*time.Minute/time.Millisecond), info.AgeMs)
+		assert.Equal(t, int64(time.Minute/time.Millisecond), info.TTLMs)
+		assert.True(t, info.Stale)
+		assert.Equal(t, "value", info.Value)
+
+		_, err = svc.GetCacheEntry(context.Background(), "missing")
+		assert.ErrorIs(t, err, errors.ErrCacheEntryNotFound)
+
+		// Inspecting does not count as lookups
+		stats, err := svc.GetCacheStats(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, domain.CacheStats{}, stats)
+	})
+
+	t.Run("PurgeRoute", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		resp, err := svc.PurgeCache(context.Background(), domain.CachePurgeRequest{Origin: "CGK", Destination: "DPS"})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, resp.Purged)
+
+		keys, _ := svc.ListCacheKeys(context.Background(), "")
+		assert.Len(t, keys, 3)
+		for _, key := range keys {
+			assert.Contains(t, key, "destination=SIN;")
+		}
+	})
+
+	t.Run("PurgeProvider", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		// The search results hold garuda's flights too
+		resp, err := svc.PurgeCache(context.Background(), domain.CachePurgeRequest{Provider: consts.ProviderKeyGarudaIndonesia})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, resp.Purged)
+
+		keys, _ := svc.ListCacheKeys(context.Background(), "")
+		assert.Equal(t, []string{cgkDps.ToProviderCacheKey(consts.ProviderKeyLionAir), cgkSin.ToProviderCacheKey(consts.ProviderKeyLionAir)}, keys)
+	})
+
+	t.Run("PurgeProvider_OnRoute", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		resp, err := svc.PurgeCache(context.Background(), domain.CachePurgeRequest{Origin: "CGK", Destination: "SIN", Provider: consts.ProviderKeyLionAir})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, resp.Purged)
+	})
+
+	t.Run("InvalidPurge", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		_, err := svc.PurgeCache(context.Background(), domain.CachePurgeRequest{})
+		assert.ErrorIs(t, err, errors.ErrInvalidCachePurge)
+		_, err = svc.PurgeCache(context.Background(), domain.CachePurgeRequest{Origin: "CGK"})
+		assert.ErrorIs(t, err, errors.ErrInvalidCachePurge)
+		_, err = svc.PurgeCache(context.Background(), domain.CachePurgeRequest{Provider: "sriwijaya"})
+		assert.ErrorIs(t, err, errors.ErrCacheUnknownProvider)
+	})
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+)
+
+// searchCodec stores search results as JSON together with the flight fields
+// the API leaves out, so that results read back from Redis still tell which
+// provider each flight came from.
+type searchCodec struct{}
+
+// cachedSearch is the JSON stored for a search result. Internals holds the
+// hidden fields of Flights, in the same order.
+type cachedSearch struct {
+	domain.SearchResponse
+	Internals []flightInternals `json:"internals"`
+}
+
+type flightInternals struct {
+	SelectedFare      domain.FareOffer   `json:"selected_fare"`
+	TotalTripDuration int64              `json:"total_trip_duration"`
+	ProviderKey       consts.ProviderKey `json:"provider_key"`
+}
+
+func (searchCodec) Marshal(value any) ([]byte, error) {
+	resp, ok := value.(domain.SearchResponse)
+	if !ok {
+		return nil, fmt.Errorf("search codec: unexpected value %T", value)
+	}
+	c := cachedSearch{SearchResponse: resp, Internals: make([]flightInternals, len(resp.Flights))}
+	for i, f := range resp.Flights {
+		c.Internals[i] = flightInternals{
+			SelectedFare:      f.SelectedFare,
+			TotalTripDuration: f.TotalTripDuration,
+			ProviderKey:       f.ProviderKey,
+		}
+	}
+	return json.Marshal(c)
+}
+
+func (searchCodec) Unmarshal(data []byte) (any, error) {
+	var c cachedSearch
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if len(c.Internals) == len(c.Flights) {
+		for i, in := range c.Internals {
+			f := &c.Flights[i]
+			f.SelectedFare = in.SelectedFare
+			f.TotalTripDuration = in.TotalTripDuration
+			f.ProviderKey = in.ProviderKey
+		}
+	}
+	return c.SearchResponse, nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/util"
+	"github.com/azcov/bookcabin_test/pkg/logger"
+)
+
+// saveToCache caches result under key for as long as the cache policy allows
+// and reports whether it was stored.
+func (fs *flightService) saveToCache(ctx context.Context, key string, input *domain.SearchRequest, result *domain.SearchResponse) bool {
+	unlock := fs.writes.lock(key)
+	defer unlock()
+	return fs.saveToCacheLocked(ctx, key, input, result)
+}
+
+// saveToCacheLocked is saveToCache for a caller holding the write lock of key.
+func (fs *flightService) saveToCacheLocked(ctx context.Context, key string, input *domain.SearchRequest, result *domain.SearchResponse) bool {
+	ttl, ok := fs.cacheTTL(input, result)
+	if !ok {
+		return false
+	}
+	return fs.setCache(ctx, key, result, ttl)
+}
+
+// setCache stores result under key, a ttl of 0 meaning the cache's default
+// expiration.
+func (fs *flightService) setCache(ctx context.Context, key string, result *domain.SearchResponse, ttl time.Duration) bool {
+	var err error
+	if stale := fs.softTTL(ttl); stale > 0 {
+		err = fs.cache.SetWithStale(key, *result, stale, ttl)
+	} else if ttl == 0 {
+		err = fs.cache.Set(key, *result)
+	} else {
+		err = fs.cache.SetWithExpiration(key, *result, ttl)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error saving to cache", "err", err)
+		return false
+	}
+	return true
+}
+
+// keyLocks serializes the writes of the results cached under a key within
+// this process. Keys share a fixed set of locks. The zero value is ready to
+// use.
+type keyLocks [64]sync.Mutex
+
+// lock locks key and returns its unlock.
+func (l *keyLocks) lock(key string) (unlock func()) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	m := &l[h.Sum32()%uint32(len(l))]
+	m.Lock()
+	return m.Unlock
+}
+
+// cacheTTL returns how long a result is cached, 0 meaning the cache's default
+// expiration, and false when it must not be cached at all. Results without
+// flights and degraded results are kept for a shorter time.
+func (fs *flightService) cacheTTL(input *domain.SearchRequest, result *domain.SearchResponse) (time.Duration, bool) {
+	return fs.limitTTL(fs.routeTTL(input), result)
+}
+
+// limitTTL shortens ttl for results without flights and degraded results,
+// returning false when they must not be cached.
+func (fs *flightService) limitTTL(ttl time.Duration, result *domain.SearchResponse) (time.Duration, bool) {
+	if len(result.Flights) == 0 {
+		if fs.cachePolicy.EmptyTTLSeconds <= 0 {
+			return 0, false
+		}
+		ttl = capTTL(ttl, time.Duration(fs.cachePolicy.EmptyTTLSeconds)*time.Second)
+	}
+	if result.Metadata.Degraded() {
+		if !fs.search.CacheDegraded {
+			return 0, false
+		}
+		ttl = capTTL(ttl, time.Duration(fs.search.DegradedCacheTTLSeconds)*time.Second)
+	}
+	return ttl, true
+}
+
+// routeTTL returns the TTL of the route, grown by PerDayPercent for every day
+// until departure.
+func (fs *flightService) routeTTL(input *domain.SearchRequest) time.Duration {
+	ttl := fs.defaultTTL
+	if minutes, ok := fs.cachePolicy.RouteTTLMinutes[input.Origin+"-"+input.Destination]; ok {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+	if ttl <= 0 || fs.cachePolicy.PerDayPercent <= 0 {
+		return ttl
+	}
+
+	days := fs.daysUntilDeparture(input)
+	ttl += ttl * time.Duration(days*fs.cachePolicy.PerDayPercent) / 100
+	if fs.cachePolicy.MaxTTLMinutes > 0 {
+		ttl = min(ttl, time.Duration(fs.cachePolicy.MaxTTLMinutes)*time.Minute)
+	}
+	return ttl
+}
+
+// daysUntilDeparture counts the days from today at the origin airport to the
+// departure date, 0 for past or unparseable dates.
+func (fs *flightService) daysUntilDeparture(input *domain.SearchRequest) int {
+	departure, err := time.Parse(util.DateFormat, input.DepartureDate)
+	if err != nil {
+		return 0
+	}
+	today, err := time.Parse(util.DateFormat, util.AirportLocalDate(fs.clock.Now(), input.Origin))
+	if err != nil {
+		return 0
+	}
+	return max(int(departure.Sub(today)/(24*time.Hour)), 0)
+}
+
+// softTTL returns after how long of ttl a result turns stale, 0 meaning
+// never. Results are only served stale when the refresher can revalidate them.
+func (fs *flightService) softTTL(ttl time.Duration) time.Duration {
+	if fs.refresher == nil || fs.cachePolicy.SoftTTLPercent <= 0 || fs.cachePolicy.SoftTTLPercent >= 100 {
+		return 0
+	}
+	if ttl == 0 {
+		ttl = fs.defaultTTL
+	}
+	return ttl * time.Duration(fs.cachePolicy.SoftTTLPercent) / 100
+}
+
+// capTTL limits ttl to limit; a ttl of 0 stands for the default expiration,
+// which is not known here, and is replaced by limit.
+func capTTL(ttl, limit time.Duration) time.Duration {
+	if ttl == 0 || ttl > limit {
+		return limit
+	}
+	return ttl
+}
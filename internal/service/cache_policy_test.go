@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFlightService_CacheTTL(t *testing.T) {
+	// 2025-12-15 07:00 in Jakarta
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	policy := config.CachePolicyConfig{
+		RouteTTLMinutes: map[string]int{"CGK-SIN": 2},
+		PerDayPercent:   10,
+		MaxTTLMinutes:   20,
+		EmptyTTLSeconds: 30,
+	}
+	flights := []domain.FlightInfo{{ID: "f1"}}
+
+	tests := []struct {
+		name     string
+		policy   config.CachePolicyConfig
+		search   config.SearchConfig
+		input    domain.SearchRequest
+		result   domain.SearchResponse
+		expected time.Duration
+		cached   bool
+	}{
+		{
+			name:     "Departure today uses the default TTL",
+			policy:   policy,
+			input:    domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-15"},
+			result:   domain.SearchResponse{Flights: flights},
+			expected: 10 * time.Minute,
+			cached:   true,
+		},
+		{
+			name:     "TTL grows with days until departure",
+			policy:   policy,
+			input:    domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-20"},
+			result:   domain.SearchResponse{Flights: flights},
+			expected: 15 * time.Minute,
+			cached:   true,
+		},
+		{
+			name:     "TTL is capped",
+			policy:   policy,
+			input:    domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2026-03-01"},
+			result:   domain.SearchResponse{Flights: flights},
+			expected: 20 * time.Minute,
+			cached:   true,
+		},
+		{
+			name:     "Past departure uses the default TTL",
+			policy:   policy,
+			input:    domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-01"},
+			result:   domain.SearchResponse{Flights: flights},
+			expected: 10 * time.Minute,
+			cached:   true,
+		},
+		{
+			name:     "Route override",
+			policy:   policy,
+			input:    domain.SearchRequest{Origin: "CGK", Destination: "SIN", DepartureDate: "2025-12-25"},
+			result:   domain.SearchResponse{Flights: flights},
+			expected: 4 * time.Minute,
+			cached:   true,
+		},
+		{
+			name:     "Empty result is cached briefly",
+			policy:   policy,
+			input:    domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-20"},
+			result:   domain.SearchResponse{Flights: []domain.FlightInfo{}},
+			expected: 30 * time.Second,
+			cached:   true,
+		},
+		{
+			name:   "Empty result is not cached without negative caching",
+			policy: config.CachePolicyConfig{},
+			input:  domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-20"},
+			result: domain.SearchResponse{Flights: []domain.FlightInfo{}},
+			cached: false,
+		},
+		{
+			name:     "Degraded result is capped",
+			policy:   policy,
+			search:   config.SearchConfig{CacheDegraded: true, DegradedCacheTTLSeconds: 10},
+			input:    domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-20"},
+			result:   domain.SearchResponse{Flights: flights, Metadata: domain.SearchMetadata{DegradedProviders: []string{"lion"}}},
+			expected: 10 * time.Second,
+			cached:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &flightService{clock: clk, defaultTTL: 10 * time.Minute, cachePolicy: tt.policy, search: tt.search}
+
+			ttl, ok := svc.cacheTTL(&tt.input, &tt.result)
+
+			assert.Equal(t, tt.cached, ok)
+			assert.Equal(t, tt.expected, ttl)
+		})
+	}
+}
+
+func TestFlightService_SerchFlight_CacheDisabled(t *testing.T) {
+	mockProvider := new(MockAirlineAggregator)
+	cfg := config.Config{
+		Cache:  cache.CacheConfig{Enabled: false},
+		Search: config.SearchConfig{RefreshWorkers: 1},
+	}
+	svc := NewFlightService(cfg, mockProvider, clock.New())
+
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	mockProvider.On("SearchFlights", mock.Anything, req).Return(&domain.SearchResponse{Flights: []domain.FlightInfo{{ID: "f1"}}}, nil)
+
+	for range 2 {
+		resp, err := svc.SerchFlight(context.Background(), &req)
+		assert.NoError(t, err)
+		assert.False(t, resp.Metadata.CacheHit)
+	}
+	mockProvider.AssertNumberOfCalls(t, "SearchFlights", 2)
+}
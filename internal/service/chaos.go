@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"github.com/azcov/bookcabin_test/internal/chaos"
+	"github.com/azcov/bookcabin_test/internal/consts"
+)
+
+type ChaosInterface interface {
+	GetChaosConfig(ctx context.Context) chaos.Config
+	UpdateChaosConfig(ctx context.Context, cfg chaos.Config) (chaos.Config, error)
+	UpdateProviderChaos(ctx context.Context, key consts.ProviderKey, pc chaos.ProviderConfig) (chaos.Config, error)
+}
+
+type chaosService struct {
+	injector *chaos.Injector
+}
+
+func NewChaosService(injector *chaos.Injector) ChaosInterface {
+	return &chaosService{injector: injector}
+}
+
+func (cs *chaosService) GetChaosConfig(ctx context.Context) chaos.Config {
+	return cs.injector.Config()
+}
+
+func (cs *chaosService) UpdateChaosConfig(ctx context.Context, cfg chaos.Config) (chaos.Config, error) {
+	if err := cs.injector.SetConfig(cfg); err != nil {
+		return chaos.Config{}, err
+	}
+	return cs.injector.Config(), nil
+}
+
+func (cs *chaosService) UpdateProviderChaos(ctx context.Context, key consts.ProviderKey, pc chaos.ProviderConfig) (chaos.Config, error) {
+	if err := cs.injector.SetProvider(key, pc); err != nil {
+		return chaos.Config{}, err
+	}
+	return cs.injector.Config(), nil
+}
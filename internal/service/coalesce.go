@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/errors"
+	"github.com/azcov/bookcabin_test/pkg/logger"
+)
+
+// coalescer lets concurrent identical searches share one provider call. The
+// call runs detached from the callers' contexts, so a caller giving up does
+// not fail the others; it is cancelled once every caller has given up.
+// The zero value is ready to use.
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done    chan struct{}
+	resp    *domain.SearchResponse
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do runs fn once for all concurrent callers with the same key and returns a
+// copy of its response to each of them, or ctx.Err() to a caller whose ctx
+// ends first. shared reports whether the call was started by another caller.
+func (c *coalescer) do(ctx context.Context, key string, fn func(ctx context.Context) (*domain.SearchResponse, error)) (resp *domain.SearchResponse, shared bool, err error) {
+	c.mu.Lock()
+	if c.calls == nil {
+		c.calls = make(map[string]*coalescedCall)
+	}
+	call, shared := c.calls[key]
+	if !shared {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		c.calls[key] = call
+		go c.run(callCtx, key, call, fn)
+	}
+	call.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, shared, call.err
+		}
+		// Callers adjust their own metadata
+		cp := *call.resp
+		return &cp, shared, nil
+	case <-ctx.Done():
+		c.leave(key, call)
+		return nil, shared, ctx.Err()
+	}
+}
+
+// run calls fn for call. A panic of fn fails the call rather than leaving
+// its callers waiting.
+func (c *coalescer) run(ctx context.Context, key string, call *coalescedCall, fn func(ctx context.Context) (*domain.SearchResponse, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorContext(ctx, "panic_recovered", "err", r)
+			call.resp, call.err = nil, errors.ErrSearchFailed
+		}
+		call.cancel()
+
+		c.mu.Lock()
+		if c.calls[key] == call {
+			delete(c.calls, key)
+		}
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	call.resp, call.err = fn(ctx)
+}
+
+// leave drops a caller whose context ended, cancelling the call when no one
+// is waiting for it anymore. Later callers then start a new call.
+func (c *coalescer) leave(key string, call *coalescedCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	call.cancel()
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+}
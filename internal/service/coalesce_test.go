@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/errors"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// waitForWaiters waits up to a second for n callers to wait on the call
+// under key, stopping the test if they never do.
+func waitForWaiters(t *testing.T, c *coalescer, key string, n int) {
+	t.Helper()
+	joined := assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		call, ok := c.calls[key]
+		return ok && call.waiters >= n
+	}, time.Second, time.Millisecond)
+	if !joined {
+		// The call would never be released
+		t.FailNow()
+	}
+}
+
+func TestFlightService_SerchFlight_Coalesced(t *testing.T) {
+	mockProvider := new(MockAirlineAggregator)
+	svc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewNoop(),
+		clock:           clock.New(),
+	}
+
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	release := make(chan struct{})
+	mockProvider.On("SearchFlights", mock.Anything, req).
+		Run(func(mock.Arguments) { <-release }).
+		Return(&domain.SearchResponse{Flights: []domain.FlightInfo{{ID: "f1"}}}, nil)
+
+	const searches = 50
+	var wg sync.WaitGroup
+	responses := make([]*domain.SearchResponse, searches)
+	for i := range searches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			input := req
+			resp, err := svc.SerchFlight(context.Background(), &input)
+			assert.NoError(t, err)
+			responses[i] = resp
+		}()
+	}
+	waitForWaiters(t, &svc.inflight, req.ToProviderCacheKey("")+";mode=;maxWaitMs=0", searches)
+	close(release)
+	wg.Wait()
+
+	mockProvider.AssertNumberOfCalls(t, "SearchFlights", 1)
+	for _, resp := range responses {
+		assert.Equal(t, "f1", resp.Flights[0].ID)
+	}
+	// Each caller owns its response
+	assert.NotSame(t, responses[0], responses[1])
+}
+
+func TestCoalescer_Do(t *testing.T) {
+	t.Run("Panic_FailsEveryCaller", func(t *testing.T) {
+		var c coalescer
+		release := make(chan struct{})
+		fn := func(ctx context.Context) (*domain.SearchResponse, error) {
+			<-release
+			panic("provider bug")
+		}
+
+		errs := make(chan error, 2)
+		for range 2 {
+			go func() {
+				_, _, err := c.do(context.Background(), "key", fn)
+				errs <- err
+			}()
+		}
+		waitForWaiters(t, &c, "key", 2)
+		close(release)
+
+		for range 2 {
+			assert.ErrorIs(t, <-errs, errors.ErrSearchFailed)
+		}
+		c.mu.Lock()
+		assert.Empty(t, c.calls)
+		c.mu.Unlock()
+	})
+
+	t.Run("CallerCancelled_OthersSucceed", func(t *testing.T) {
+		var c coalescer
+		release := make(chan struct{})
+		var callCtx context.Context
+		fn := func(ctx context.Context) (*domain.SearchResponse, error) {
+			callCtx = ctx
+			<-release
+			return &domain.SearchResponse{Flights: []domain.FlightInfo{{ID: "f1"}}}, nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		leaderErr := make(chan error, 1)
+		go func() {
+			_, _, err := c.do(ctx, "key", fn)
+			leaderErr <- err
+		}()
+		waitForWaiters(t, &c, "key", 1)
+
+		followerResp := make(chan *domain.SearchResponse, 1)
+		go func() {
+			resp, shared, err := c.do(context.Background(), "key", fn)
+			assert.NoError(t, err)
+			assert.True(t, shared)
+			followerResp <- resp
+		}()
+		waitForWaiters(t, &c, "key", 2)
+
+		cancel()
+		assert.ErrorIs(t, <-leaderErr, context.Canceled)
+		close(release)
+
+		assert.Equal(t, "f1", (<-followerResp).Flights[0].ID)
+		assert.ErrorIs(t, callCtx.Err(), context.Canceled, "call context is released once done")
+	})
+
+	t.Run("EveryCallerLeft_CallCancelled", func(t *testing.T) {
+		var c coalescer
+		cancelled := make(chan struct{})
+		fn := func(ctx context.Context) (*domain.SearchResponse, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			_, _, err := c.do(ctx, "key", fn)
+			done <- err
+		}()
+		waitForWaiters(t, &c, "key", 1)
+		cancel()
+
+		assert.ErrorIs(t, <-done, context.Canceled)
+		<-cancelled
+		c.mu.Lock()
+		assert.Empty(t, c.calls)
+		c.mu.Unlock()
+	})
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"slices"
 	"sort"
 	"time"
 
@@ -10,72 +11,202 @@ import (
 	"github.com/azcov/bookcabin_test/internal/domain"
 	"github.com/azcov/bookcabin_test/internal/provider"
 	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
 	"github.com/azcov/bookcabin_test/pkg/logger"
+	"github.com/azcov/bookcabin_test/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type FlightInterface interface {
 	SerchFlight(ctx context.Context, input *domain.SearchRequest) (*domain.SearchResponse, error)
+	// Close stops the background refreshes, see refresher.close.
+	Close(ctx context.Context) error
 }
 
 type flightService struct {
 	airlaneProvider provider.AirlineAggregator
 	cache           cache.Cache
+	clock           clock.Clock
+	search          config.SearchConfig
+	refresher       *refresher
+	inflight        coalescer
+	writes          keyLocks
+	// defaultTTL of 0 leaves expiration to the cache
+	defaultTTL  time.Duration
+	cachePolicy config.CachePolicyConfig
 }
 
-func NewFlightService(cfg config.Config) FlightInterface {
-	airlaneProvider := provider.NewAirlineProvider()
-	return &flightService{
+// NewSearchCache returns the cache of search results configured by cfg.
+func NewSearchCache(cfg cache.CacheConfig, clk clock.Clock) cache.Cache {
+	return cache.New(cfg, clk, searchCodec{})
+}
+
+func NewFlightService(cfg config.Config, airlaneProvider provider.AirlineAggregator, clk clock.Clock) FlightInterface {
+	return NewFlightServiceWithCache(cfg, airlaneProvider, NewSearchCache(cfg.Cache, clk), clk)
+}
+
+// NewFlightServiceWithCache is NewFlightService on an existing search cache,
+// e.g. one shared with the cache administration.
+func NewFlightServiceWithCache(cfg config.Config, airlaneProvider provider.AirlineAggregator, c cache.Cache, clk clock.Clock) FlightInterface {
+	fs := &flightService{
 		airlaneProvider: airlaneProvider,
-		cache:           cache.NewGoCache(cfg.Cache),
+		cache:           c,
+		clock:           clk,
+		search:          cfg.Search,
+		defaultTTL:      time.Duration(cfg.Cache.ExpirationMinute) * time.Minute,
+		cachePolicy:     cfg.CachePolicy,
+	}
+	if cfg.Cache.Enabled && cfg.Search.RefreshWorkers > 0 {
+		fs.refresher = newRefresher(cfg.Search.RefreshQueueSize)
+		fs.refresher.start(cfg.Search.RefreshWorkers, fs.refreshWorker)
+	}
+	return fs
+}
+
+func (fs *flightService) Close(ctx context.Context) error {
+	if fs.refresher == nil {
+		return nil
 	}
+	return fs.refresher.close(ctx)
 }
 
-func (fs *flightService) SerchFlight(ctx context.Context, input *domain.SearchRequest) (*domain.SearchResponse, error) {
-	start := time.Now()
+func (fs *flightService) SerchFlight(ctx context.Context, input *domain.SearchRequest) (resp *domain.SearchResponse, err error) {
+	start := fs.clock.Now()
+	fs.resolveMode(input)
+
+	ctx, span := tracing.Start(ctx, "flightService.SerchFlight", trace.WithAttributes(
+		attribute.String("origin", input.Origin),
+		attribute.String("destination", input.Destination),
+		attribute.String("departure_date", input.DepartureDate),
+		attribute.String("mode", string(input.Mode)),
+	))
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(
+				attribute.Bool("cache_hit", resp.Metadata.CacheHit),
+				attribute.Bool("stale", resp.Metadata.Stale),
+				attribute.Int("results", len(resp.Flights)),
+			)
+		}
+		tracing.End(span, err)
+	}()
 
 	// 1. Check Cache
 	cacheKey := input.ToCacheKey()
-	cachedData, err := fs.cache.Get(cacheKey)
-	if err == nil {
-		data := cachedData.(domain.SearchResponse)
+	ctx = logger.WithContext(ctx, "search_key", cacheKey)
+	if data, lookup := fs.lookupResults(ctx, cacheKey, input); lookup != lookupMiss {
+		if lookup == lookupStale {
+			data.Metadata.Stale = true
+			fs.scheduleRevalidate(ctx, cacheKey, *input)
+		} else {
+			fs.scheduleRefresh(ctx, cacheKey, *input, data.Metadata)
+		}
 		data.Metadata.CacheHit = true
-		data.Metadata.SearchTimeMs = int(time.Since(start).Milliseconds())
+		data.Metadata.SearchTimeMs = int(fs.clock.Since(start).Milliseconds())
+		searchResults.Observe(float64(len(data.Flights)))
 		return &data, nil
 	}
 
-	// 2. Call Providers
-	result, err := fs.airlaneProvider.SearchFlights(ctx, *input)
+	// 2. Get Raw Provider Results
+	raw, err := fs.rawResults(ctx, input)
 	if err != nil {
-		logger.ErrorContext(ctx, "Error : ", "err", err)
+		logger.ErrorContext(ctx, "Error searching flights", "err", err)
 		return nil, err
 	}
 
-	// 3. Filter Results
-	result.Flights = fs.filterFlights(result.Flights, input.Filters)
-
-	// 4. Calculate Best Value Score (Ranking)
-	fs.calculateBestValue(result.Flights)
-
-	// 5. Sort Results
-	fs.sortFlights(result.Flights, input.Sort)
+	// 3. - 5. Select Fare Offers, Filter, Rank and Sort Results. Ranking
+	// copies the flights, the raw results may be cached and shared.
+	result := *raw
+	result.Flights = fs.rankFlights(raw.Flights, input)
 
 	// Update Metadata
 	result.Metadata.TotalResults = len(result.Flights)
 	result.SearchCriteria = *input
-	result.Metadata.SearchTimeMs = int(time.Since(start).Milliseconds())
+	result.Metadata.SearchTimeMs = int(fs.clock.Since(start).Milliseconds())
 	result.Metadata.CacheHit = false
 
-	// 6. Save to Cache
-	err = fs.cache.SetWithExpiration(cacheKey, *result, time.Second)
+	// 6. Save to Cache. Results built from stale raw results are cached once
+	// revalidated.
+	if result.Metadata.Stale {
+		fs.scheduleRevalidate(ctx, cacheKey, *input)
+	} else if fs.saveToCache(ctx, cacheKey, input, &result) {
+		fs.scheduleRefresh(ctx, cacheKey, *input, result.Metadata)
+	}
+
+	searchResults.Observe(float64(len(result.Flights)))
+	return &result, nil
+}
+
+// lookupResults looks the search up in the results cache. Entries the search
+// cannot use are misses.
+func (fs *flightService) lookupResults(ctx context.Context, key string, input *domain.SearchRequest) (data domain.SearchResponse, lookup string) {
+	_, span := tracing.Start(ctx, "cache.lookup", trace.WithAttributes(attribute.String("cache", cacheResults)))
+	defer func() {
+		cacheLookups.WithLabelValues(cacheResults, lookup).Inc()
+		span.SetAttributes(attribute.String("result", lookup))
+		span.End()
+	}()
+
+	entry, err := fs.cache.GetEntry(key)
 	if err != nil {
-		logger.ErrorContext(ctx, "Error saving to cache", "err", err)
+		return data, lookupMiss
 	}
+	// An entry the codec did not decode as a result is searched again
+	data, ok := entry.Value.(domain.SearchResponse)
+	if !ok {
+		return domain.SearchResponse{}, lookupMiss
+	}
+	// A client waiting for all providers is not served a degraded result
+	if data.Metadata.Degraded() && input.Mode != consts.SearchModeFast {
+		return domain.SearchResponse{}, lookupMiss
+	}
+	if entry.Stale(fs.clock.Now()) {
+		return data, lookupStale
+	}
+	return data, lookupHit
+}
 
-	return result, nil
+// rankFlights selects the fare offers, filters, scores and sorts the flights
+// as requested.
+func (fs *flightService) rankFlights(flights []domain.FlightInfo, input *domain.SearchRequest) []domain.FlightInfo {
+	flights = fs.selectFareOffers(flights, input.FareBrand)
+	flights = fs.filterFlights(flights, input.Filters)
+	fs.calculateBestValue(flights)
+	fs.sortFlights(flights, input.Sort)
+	return flights
+}
+
+// resolveMode fills in the configured search mode and fast wait when the
+// client did not set them.
+func (fs *flightService) resolveMode(input *domain.SearchRequest) {
+	if input.Mode == "" {
+		input.Mode = fs.search.DefaultMode
+	}
+	if input.Mode == consts.SearchModeFast && input.MaxWaitMs == 0 {
+		input.MaxWaitMs = fs.search.FastWaitMs
+	}
 }
 
 // --- Aggregation Logic ---
 
+// selectFareOffers picks the requested fare brand, or the cheapest offer when
+// none is requested, as the fare filters and sorting operate on. Flights not
+// selling the requested brand are dropped.
+func (fs *flightService) selectFareOffers(flights []domain.FlightInfo, brand consts.FareBrand) []domain.FlightInfo {
+	selected := make([]domain.FlightInfo, 0, len(flights))
+	for _, f := range flights {
+		if len(f.FareOffers) == 0 && brand == "" {
+			selected = append(selected, f)
+			continue
+		}
+		if f.SelectFareOffer(brand) {
+			selected = append(selected, f)
+		}
+	}
+	return selected
+}
+
 func (fs *flightService) filterFlights(flights []domain.FlightInfo, filters []domain.SearchFilter) []domain.FlightInfo {
 	if len(filters) == 0 {
 		return flights
@@ -99,8 +230,8 @@ func (fs *flightService) filterFlights(flights []domain.FlightInfo, filters []do
 }
 
 func (fs *flightService) calculateBestValue(flights []domain.FlightInfo) {
-	for _, f := range flights {
-		f.CalculateBestValueScore()
+	for i := range flights {
+		flights[i].CalculateBestValueScore()
 	}
 }
 
@@ -126,10 +257,59 @@ func (fs *flightService) applyFilter(f domain.FlightInfo, filter domain.SearchFi
 		if val, ok := filter.Value.(string); ok {
 			return f.Airline.Code == val || f.Airline.Name == val
 		}
+	case consts.FilterKeyMaxLayoverDuration: // Minutes
+		if val, ok := filter.Value.(float64); ok {
+			for _, l := range f.Layovers {
+				if float64(l.Duration.TotalMinutes) > val {
+					return false
+				}
+			}
+		}
+	case consts.FilterKeyRefundable:
+		if val, ok := filter.Value.(bool); ok {
+			return f.SelectedFare.Refundable == val
+		}
+	case consts.FilterKeyCheckedBaggage:
+		if val, ok := filter.Value.(bool); ok {
+			return f.Baggage.Checked.Included == val
+		}
+	case consts.FilterKeyAmenities:
+		for _, a := range toStringSlice(filter.Value) {
+			if !f.HasAmenity(consts.AmenityType(a)) {
+				return false
+			}
+		}
+	case consts.FilterKeyAvoidLayoverAirport:
+		airports := toStringSlice(filter.Value)
+		for _, l := range f.Layovers {
+			if slices.Contains(airports, l.Airport) {
+				return false
+			}
+		}
 	}
 	return true
 }
 
+// toStringSlice accepts a filter value given either as a single string or as
+// a JSON array of strings.
+func toStringSlice(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []string:
+		return val
+	case []any:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
 func (fs *flightService) sortFlights(flights []domain.FlightInfo, sortOpt domain.SortOption) {
 	sort.SliceStable(flights, func(i, j int) bool {
 		a, b := flights[i], flights[j]
@@ -6,8 +6,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/azcov/bookcabin_test/internal/config"
 	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -25,6 +29,19 @@ func (m *MockAirlineAggregator) SearchFlights(ctx context.Context, input domain.
 	return args.Get(0).(*domain.SearchResponse), args.Error(1)
 }
 
+func (m *MockAirlineAggregator) Quota(key consts.ProviderKey) float64 {
+	args := m.Called(key)
+	return args.Get(0).(float64)
+}
+
+func (m *MockAirlineAggregator) SearchProviders(ctx context.Context, input domain.SearchRequest, keys []consts.ProviderKey) (*domain.SearchResponse, error) {
+	args := m.Called(ctx, input, keys)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SearchResponse), args.Error(1)
+}
+
 // MockCache
 type MockCache struct {
 	mock.Mock
@@ -45,11 +62,37 @@ func (m *MockCache) SetWithExpiration(key string, value any, exp time.Duration)
 	return args.Error(0)
 }
 
+func (m *MockCache) SetWithStale(key string, value any, stale, exp time.Duration) error {
+	args := m.Called(key, value, stale, exp)
+	return args.Error(0)
+}
+
+// GetEntry is served by the expectations on Get, its entries never go stale.
+func (m *MockCache) GetEntry(key string) (cache.Entry, error) {
+	value, err := m.Get(key)
+	return cache.Entry{Value: value}, err
+}
+
 func (m *MockCache) Delete(key string) error {
 	args := m.Called(key)
 	return args.Error(0)
 }
 
+func (m *MockCache) Keys(prefix string) ([]string, error) {
+	args := m.Called(prefix)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockCache) Peek(key string) (cache.Entry, error) {
+	args := m.Called(key)
+	return args.Get(0).(cache.Entry), args.Error(1)
+}
+
+func (m *MockCache) Stats() (cache.Stats, error) {
+	args := m.Called()
+	return args.Get(0).(cache.Stats), args.Error(1)
+}
+
 func TestFlightService_SerchFlight(t *testing.T) {
 	t.Run("CacheHit", func(t *testing.T) {
 		mockProvider := new(MockAirlineAggregator)
@@ -57,13 +100,14 @@ func TestFlightService_SerchFlight(t *testing.T) {
 		svc := &flightService{
 			airlaneProvider: mockProvider,
 			cache:           mockCache,
+			clock:           clock.New(),
 		}
 
 		req := domain.SearchRequest{
 			Origin:        "CGK",
 			Destination:   "DPS",
 			DepartureDate: "2025-12-25",
-			Passengers:    1,
+			Passengers:    domain.PassengerCount{Adults: 1},
 			CabinClass:    "Economy",
 		}
 		cacheKey := req.ToCacheKey()
@@ -93,13 +137,14 @@ func TestFlightService_SerchFlight(t *testing.T) {
 		svc := &flightService{
 			airlaneProvider: mockProvider,
 			cache:           mockCache,
+			clock:           clock.New(),
 		}
 
 		req := domain.SearchRequest{
 			Origin:        "CGK",
 			Destination:   "DPS",
 			DepartureDate: "2025-12-25",
-			Passengers:    1,
+			Passengers:    domain.PassengerCount{Adults: 1},
 			CabinClass:    "Economy",
 		}
 		cacheKey := req.ToCacheKey()
@@ -133,6 +178,7 @@ func TestFlightService_SerchFlight(t *testing.T) {
 		svc := &flightService{
 			airlaneProvider: mockProvider,
 			cache:           mockCache,
+			clock:           clock.New(),
 		}
 
 		req := domain.SearchRequest{
@@ -158,6 +204,7 @@ func TestFlightService_SerchFlight(t *testing.T) {
 		svc := &flightService{
 			airlaneProvider: mockProvider,
 			cache:           mockCache,
+			clock:           clock.New(),
 		}
 
 		req := domain.SearchRequest{
@@ -189,6 +236,7 @@ func TestFlightService_SerchFlight(t *testing.T) {
 		svc := &flightService{
 			airlaneProvider: mockProvider,
 			cache:           mockCache,
+			clock:           clock.New(),
 		}
 
 		req := domain.SearchRequest{
@@ -213,3 +261,317 @@ func TestFlightService_SerchFlight(t *testing.T) {
 		assert.Equal(t, "low", resp.Flights[1].ID)
 	})
 }
+
+func TestFlightService_FilterFlights_Layovers(t *testing.T) {
+	direct := domain.FlightInfo{ID: "direct", Layovers: []domain.Layover{}}
+	shortSUB := domain.FlightInfo{ID: "short_sub", Layovers: []domain.Layover{domain.NewLayover("SUB", 45)}}
+	longUPG := domain.FlightInfo{ID: "long_upg", Layovers: []domain.Layover{domain.NewLayover("UPG", 180)}}
+
+	tests := []struct {
+		name     string
+		filter   domain.SearchFilter
+		expected []string
+	}{
+		{
+			name:     "MaxLayoverDuration",
+			filter:   domain.SearchFilter{Key: consts.FilterKeyMaxLayoverDuration, Value: 60.0},
+			expected: []string{"direct", "short_sub"},
+		},
+		{
+			name:     "AvoidLayoverAirport",
+			filter:   domain.SearchFilter{Key: consts.FilterKeyAvoidLayoverAirport, Value: "SUB"},
+			expected: []string{"direct", "long_upg"},
+		},
+		{
+			name:     "AvoidLayoverAirportList",
+			filter:   domain.SearchFilter{Key: consts.FilterKeyAvoidLayoverAirport, Value: []any{"SUB", "UPG"}},
+			expected: []string{"direct"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &flightService{}
+			flights := svc.filterFlights([]domain.FlightInfo{direct, shortSUB, longUPG}, []domain.SearchFilter{tt.filter})
+
+			ids := make([]string, 0, len(flights))
+			for _, f := range flights {
+				ids = append(ids, f.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestFlightService_SelectFareOffers(t *testing.T) {
+	lite := domain.FareOffer{Brand: consts.FareBrandLite, Price: domain.PriceInfo{Amount: 1000}}
+	flex := domain.FareOffer{
+		Brand:      consts.FareBrandFlex,
+		Price:      domain.PriceInfo{Amount: 1800},
+		Refundable: true,
+		Baggage:    domain.BaggageInfo{Checked: domain.NewBaggageAllowance(0, 20, true, nil)},
+	}
+	flights := []domain.FlightInfo{
+		{ID: "lite_flex", FareOffers: []domain.FareOffer{flex, lite}},
+		{ID: "lite_only", FareOffers: []domain.FareOffer{lite}},
+	}
+
+	tests := []struct {
+		name           string
+		brand          consts.FareBrand
+		filters        []domain.SearchFilter
+		expectedIDs    []string
+		expectedPrices []int
+	}{
+		{
+			name:           "Cheapest",
+			expectedIDs:    []string{"lite_flex", "lite_only"},
+			expectedPrices: []int{1000, 1000},
+		},
+		{
+			name:           "SelectedBrand",
+			brand:          consts.FareBrandFlex,
+			expectedIDs:    []string{"lite_flex"},
+			expectedPrices: []int{1800},
+		},
+		{
+			name:           "RefundableOnSelectedBrand",
+			brand:          consts.FareBrandFlex,
+			filters:        []domain.SearchFilter{{Key: consts.FilterKeyRefundable, Value: true}},
+			expectedIDs:    []string{"lite_flex"},
+			expectedPrices: []int{1800},
+		},
+		{
+			name:           "CheckedBaggageOnSelectedBrand",
+			brand:          consts.FareBrandFlex,
+			filters:        []domain.SearchFilter{{Key: consts.FilterKeyCheckedBaggage, Value: true}},
+			expectedIDs:    []string{"lite_flex"},
+			expectedPrices: []int{1800},
+		},
+		{
+			name:           "CheckedBaggageOnCheapest",
+			filters:        []domain.SearchFilter{{Key: consts.FilterKeyCheckedBaggage, Value: true}},
+			expectedIDs:    []string{},
+			expectedPrices: []int{},
+		},
+		{
+			name:           "RefundableOnCheapest",
+			filters:        []domain.SearchFilter{{Key: consts.FilterKeyRefundable, Value: true}},
+			expectedIDs:    []string{},
+			expectedPrices: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &flightService{}
+			result := svc.selectFareOffers(flights, tt.brand)
+			result = svc.filterFlights(result, tt.filters)
+
+			ids := []string{}
+			prices := []int{}
+			for _, f := range result {
+				ids = append(ids, f.ID)
+				prices = append(prices, f.Price.Amount)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, tt.expectedPrices, prices)
+		})
+	}
+}
+
+func TestFlightService_FilterFlights_Amenities(t *testing.T) {
+	garuda := map[string]consts.AmenityType{"wifi": consts.AmenityTypeWifi, "meal": consts.AmenityTypeMeal}
+	batik := map[string]consts.AmenityType{"meal": consts.AmenityTypeMeal, "snack": consts.AmenityTypeSnack}
+	flights := []domain.FlightInfo{
+		{ID: "garuda", Amenities: []domain.AmenityInfo{domain.NewAmenityInfo(garuda, "wifi"), domain.NewAmenityInfo(garuda, "meal")}},
+		{ID: "batik", Amenities: []domain.AmenityInfo{domain.NewAmenityInfo(batik, "Meal"), domain.NewAmenityInfo(batik, "Blanket")}},
+		{ID: "none", Amenities: []domain.AmenityInfo{}},
+	}
+
+	tests := []struct {
+		name     string
+		value    any
+		expected []string
+	}{
+		{name: "SingleAcrossCarriers", value: "meal", expected: []string{"garuda", "batik"}},
+		{name: "AllRequired", value: []any{"meal", "wifi"}, expected: []string{"garuda"}},
+		{name: "UnknownPreservedAsOther", value: "other", expected: []string{"batik"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &flightService{}
+			result := svc.filterFlights(flights, []domain.SearchFilter{{Key: consts.FilterKeyAmenities, Value: tt.value}})
+
+			ids := []string{}
+			for _, f := range result {
+				ids = append(ids, f.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+
+	assert.Equal(t, "Blanket", flights[1].Amenities[1].Description)
+}
+
+func TestFlightService_SerchFlight_Clock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	mockProvider := new(MockAirlineAggregator)
+	svc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewGoCacheWithClock(cache.CacheConfig{}, clk),
+		clock:           clk,
+	}
+
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	mockProvider.On("SearchFlights", mock.Anything, req).
+		Run(func(mock.Arguments) { clk.Advance(150 * time.Millisecond) }).
+		Return(&domain.SearchResponse{Flights: []domain.FlightInfo{{ID: "f1"}}}, nil)
+
+	resp, err := svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.Equal(t, 150, resp.Metadata.SearchTimeMs)
+	assert.False(t, resp.Metadata.CacheHit)
+
+	// Served from cache until the entry expires on the clock
+	resp, err = svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.True(t, resp.Metadata.CacheHit)
+	assert.Equal(t, 0, resp.Metadata.SearchTimeMs)
+
+	// The cache's default expiration
+	clk.Advance(5 * time.Minute)
+	resp, err = svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.False(t, resp.Metadata.CacheHit)
+	mockProvider.AssertNumberOfCalls(t, "SearchFlights", 2)
+}
+
+func TestFlightService_SerchFlight_UnexpectedCacheEntry(t *testing.T) {
+	mockProvider := new(MockAirlineAggregator)
+	svc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewGoCache(cache.CacheConfig{}),
+		clock:           clock.New(),
+	}
+
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	assert.NoError(t, svc.cache.Set(req.ToCacheKey(), "not a result"))
+	mockProvider.On("SearchFlights", mock.Anything, req).Return(&domain.SearchResponse{Flights: []domain.FlightInfo{{ID: "f1"}}}, nil).Once()
+
+	// Searched again and replaced
+	resp, err := svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.False(t, resp.Metadata.CacheHit)
+	resp, err = svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.True(t, resp.Metadata.CacheHit)
+
+	t.Run("Refresh", func(t *testing.T) {
+		job := refreshJob{key: "key", input: req, providers: []consts.ProviderKey{consts.ProviderKeyLionAir}}
+		assert.NoError(t, svc.cache.Set(job.key, "not a result"))
+		mockProvider.On("SearchProviders", mock.Anything, job.input, job.providers).Return(&domain.SearchResponse{
+			Flights:  []domain.FlightInfo{{ID: "f2", ProviderKey: consts.ProviderKeyLionAir}},
+			Metadata: domain.SearchMetadata{ProvidersSucceeded: 1, Providers: []domain.ProviderMetadata{{Provider: "lion", Succeeded: true}}},
+		}, nil).Once()
+
+		svc.refresh(context.Background(), job)
+
+		value, err := svc.cache.Get(job.key)
+		assert.NoError(t, err)
+		assert.Equal(t, "not a result", value)
+	})
+}
+
+func TestFlightService_SerchFlight_Degraded(t *testing.T) {
+	degraded := func() *domain.SearchResponse {
+		return &domain.SearchResponse{
+			Flights:  []domain.FlightInfo{{ID: "f1"}},
+			Metadata: domain.SearchMetadata{DegradedProviders: []string{"lion"}},
+		}
+	}
+	policy := config.SearchConfig{DefaultMode: consts.SearchModeWaitAll, FastWaitMs: 800, CacheDegraded: true, DegradedCacheTTLSeconds: 10}
+
+	t.Run("FastMode_DefaultWait", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		mockProvider := new(MockAirlineAggregator)
+		svc := &flightService{airlaneProvider: mockProvider, cache: cache.NewGoCacheWithClock(cache.CacheConfig{}, clk), clock: clk, search: policy}
+
+		req := domain.SearchRequest{Origin: "CGK", Mode: consts.SearchModeFast}
+		expected := domain.SearchRequest{Origin: "CGK", Mode: consts.SearchModeFast, MaxWaitMs: 800}
+		mockProvider.On("SearchFlights", mock.Anything, expected).Return(degraded(), nil)
+
+		resp, err := svc.SerchFlight(context.Background(), &req)
+		assert.NoError(t, err)
+		assert.Equal(t, 800, resp.SearchCriteria.MaxWaitMs)
+		mockProvider.AssertExpectations(t)
+	})
+
+	t.Run("DegradedResult_ServedToFastOnly", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		mockProvider := new(MockAirlineAggregator)
+		svc := &flightService{airlaneProvider: mockProvider, cache: cache.NewGoCacheWithClock(cache.CacheConfig{}, clk), clock: clk, search: policy}
+		mockProvider.On("SearchFlights", mock.Anything, mock.Anything).Return(degraded(), nil)
+
+		fast := domain.SearchRequest{Origin: "CGK", Mode: consts.SearchModeFast}
+		_, err := svc.SerchFlight(context.Background(), &fast)
+		assert.NoError(t, err)
+
+		resp, err := svc.SerchFlight(context.Background(), &fast)
+		assert.NoError(t, err)
+		assert.True(t, resp.Metadata.CacheHit)
+
+		waitAll := domain.SearchRequest{Origin: "CGK"}
+		resp, err = svc.SerchFlight(context.Background(), &waitAll)
+		assert.NoError(t, err)
+		assert.False(t, resp.Metadata.CacheHit)
+		mockProvider.AssertNumberOfCalls(t, "SearchFlights", 2)
+	})
+
+	t.Run("DegradedResult_NotCached", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		mockProvider := new(MockAirlineAggregator)
+		noCache := policy
+		noCache.CacheDegraded = false
+		svc := &flightService{airlaneProvider: mockProvider, cache: cache.NewGoCacheWithClock(cache.CacheConfig{}, clk), clock: clk, search: noCache}
+		mockProvider.On("SearchFlights", mock.Anything, mock.Anything).Return(degraded(), nil)
+
+		req := domain.SearchRequest{Origin: "CGK", Mode: consts.SearchModeFast}
+		for range 2 {
+			resp, err := svc.SerchFlight(context.Background(), &req)
+			assert.NoError(t, err)
+			assert.False(t, resp.Metadata.CacheHit)
+		}
+		mockProvider.AssertNumberOfCalls(t, "SearchFlights", 2)
+	})
+}
+
+func TestFlightService_SerchFlight_RedisCache(t *testing.T) {
+	srv := miniredis.RunT(t)
+	mockProvider := new(MockAirlineAggregator)
+	svc := &flightService{
+		airlaneProvider: mockProvider,
+		cache: NewSearchCache(cache.CacheConfig{
+			Enabled: true,
+			Driver:  cache.DriverRedis,
+			Redis:   cache.RedisConfig{Addr: srv.Addr(), KeyPrefix: "bookcabin:"},
+		}, clock.New()),
+		clock: clock.New(),
+	}
+
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	mockProvider.On("SearchFlights", mock.Anything, req).Return(&domain.SearchResponse{
+		Flights: []domain.FlightInfo{{ID: "f1", Price: domain.PriceInfo{Amount: 1000, Currency: "IDR"}, Duration: domain.DurationInfo{TotalMinutes: 60}}},
+	}, nil).Once()
+
+	first, err := svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.True(t, srv.Exists("bookcabin:"+req.ToCacheKey()))
+
+	second, err := svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.True(t, second.Metadata.CacheHit)
+	assert.Equal(t, first.Flights, second.Flights)
+	mockProvider.AssertExpectations(t)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"github.com/azcov/bookcabin_test/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Caches looked up by a search, and the results of their lookups.
+const (
+	cacheResults         = "results"
+	cacheProviderResults = "provider_results"
+
+	lookupHit   = "hit"
+	lookupStale = "stale"
+	lookupMiss  = "miss"
+)
+
+// Results of the warm-up searches.
+const (
+	warmupFetched  = "fetched"
+	warmupCacheHit = "cache_hit"
+	warmupSkipped  = "skipped"
+	warmupFailed   = "failed"
+)
+
+var (
+	cacheLookups = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "Cache lookups of searches by cache and result. Entries a search cannot use, e.g. degraded ones, are misses.",
+	}, []string{"cache", "result"})
+	searchResults = metrics.Factory.NewHistogram(prometheus.HistogramOpts{
+		Name:    "search_results",
+		Help:    "Flights returned per search.",
+		Buckets: []float64{0, 1, 2, 5, 10, 20, 50, 100},
+	})
+	warmupRuns = metrics.Factory.NewCounter(prometheus.CounterOpts{
+		Name: "warmup_runs_total",
+		Help: "Cache warm-up runs.",
+	})
+	warmupSearches = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "warmup_searches_total",
+		Help: "Warm-up searches by result: fetched from the providers, cache hit, skipped for lack of provider quota or failed.",
+	}, []string{"result"})
+)
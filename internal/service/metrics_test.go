@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFlightService_SerchFlight_CacheLookupMetrics(t *testing.T) {
+	// Metrics are global, so only their increase is asserted
+	lookups := func(cache, result string) float64 {
+		return testutil.ToFloat64(cacheLookups.WithLabelValues(cache, result))
+	}
+	resultsHit, resultsMiss := lookups(cacheResults, lookupHit), lookups(cacheResults, lookupMiss)
+	rawHit, rawMiss := lookups(cacheProviderResults, lookupHit), lookups(cacheProviderResults, lookupMiss)
+
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	mockProvider := new(MockAirlineAggregator)
+	svc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewGoCacheWithClock(cache.CacheConfig{}, clk),
+		clock:           clk,
+		cachePolicy:     config.CachePolicyConfig{RawEnabled: true, RawPerProvider: true},
+	}
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	mockProvider.On("SearchFlights", mock.Anything, req).Return(providerResults(consts.ProviderKeys...), nil).Once()
+
+	for _, sort := range []consts.SortKey{"", "", consts.SortKeyPrice} {
+		input := req
+		input.Sort.Key = sort
+		_, err := svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+	}
+
+	// The repeated search hits the results, the refined one the raw results
+	assert.Equal(t, resultsHit+1, lookups(cacheResults, lookupHit))
+	assert.Equal(t, resultsMiss+2, lookups(cacheResults, lookupMiss))
+	assert.Equal(t, rawMiss+float64(len(consts.ProviderKeys)), lookups(cacheProviderResults, lookupMiss))
+	assert.Equal(t, rawHit+float64(len(consts.ProviderKeys)), lookups(cacheProviderResults, lookupHit))
+}
+
+func TestWarmer_Metrics(t *testing.T) {
+	searches := func(result string) float64 {
+		return testutil.ToFloat64(warmupSearches.WithLabelValues(result))
+	}
+	runs := testutil.ToFloat64(warmupRuns)
+	fetched, cacheHits, skipped, failed := searches(warmupFetched), searches(warmupCacheHit), searches(warmupSkipped), searches(warmupFailed)
+
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	mockProvider := new(MockAirlineAggregator)
+	flightSvc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewGoCacheWithClock(cache.CacheConfig{}, clk),
+		clock:           clk,
+	}
+	cfg := config.WarmupConfig{Enabled: true, Routes: []string{"CGK-DPS", "CGK-SUB"}, Days: 1, MinProviderQuota: 50}
+	w := NewWarmer(cfg, flightSvc, mockProvider, clk).(*warmer)
+
+	mockProvider.On("Quota", mock.Anything).Return(100.0).Times(2 * len(consts.ProviderKeys))
+	mockProvider.On("SearchFlights", mock.Anything, mock.MatchedBy(func(req domain.SearchRequest) bool { return req.Destination == "DPS" })).
+		Return(providerResults(consts.ProviderKeyGarudaIndonesia), nil)
+	mockProvider.On("SearchFlights", mock.Anything, mock.Anything).Return(nil, errors.New("provider down"))
+	w.run(context.Background())
+
+	// Cached now, and then out of quota
+	mockProvider.On("Quota", mock.Anything).Return(100.0).Times(len(consts.ProviderKeys))
+	mockProvider.On("Quota", mock.Anything).Return(10.0)
+	w.run(context.Background())
+
+	assert.Equal(t, runs+2, testutil.ToFloat64(warmupRuns))
+	assert.Equal(t, fetched+1, searches(warmupFetched))
+	assert.Equal(t, cacheHits+1, searches(warmupCacheHit))
+	assert.Equal(t, skipped+1, searches(warmupSkipped))
+	assert.Equal(t, failed+1, searches(warmupFailed))
+}
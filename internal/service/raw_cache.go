@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// rawResults returns the unfiltered provider results of a search. With the
+// raw cache enabled, only the providers missing from it are queried. Identical
+// concurrent searches share one fetch, whatever their filters and sort.
+func (fs *flightService) rawResults(ctx context.Context, input *domain.SearchRequest) (*domain.SearchResponse, error) {
+	// The mode is part of the key as it bounds how long the fetch waits
+	key := fmt.Sprintf("%s;mode=%s;maxWaitMs=%d", input.ToProviderCacheKey(""), input.Mode, input.MaxWaitMs)
+	query := *input
+	resp, _, err := fs.inflight.do(ctx, key, func(ctx context.Context) (*domain.SearchResponse, error) {
+		return fs.fetchRaw(ctx, &query, true)
+	})
+	return resp, err
+}
+
+// fetchRaw returns the raw results of input, querying the providers missing
+// from the raw cache. Stale cached results are used only when serveStale is
+// set, otherwise their providers are queried again.
+func (fs *flightService) fetchRaw(ctx context.Context, input *domain.SearchRequest, serveStale bool) (*domain.SearchResponse, error) {
+	if !fs.cachePolicy.RawEnabled {
+		return fs.airlaneProvider.SearchFlights(ctx, *input)
+	}
+
+	hits, missing := fs.getRaw(ctx, input, serveStale)
+	if len(missing) == 0 {
+		return combineResults(hits...), nil
+	}
+
+	var (
+		fresh *domain.SearchResponse
+		err   error
+	)
+	if len(hits) == 0 {
+		fresh, err = fs.airlaneProvider.SearchFlights(ctx, *input)
+	} else {
+		fresh, err = fs.airlaneProvider.SearchProviders(ctx, *input, missing)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Once every caller left, the providers cut short are not cached as
+	// failed
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if fs.cachePolicy.RawPerProvider {
+		fs.saveRawProviders(ctx, input, fresh)
+	} else if ttl, ok := fs.limitTTL(fs.routeTTL(input), fresh); ok {
+		fs.setCache(ctx, input.ToProviderCacheKey(""), fresh, ttl)
+	}
+	return combineResults(append(hits, fresh)...), nil
+}
+
+// getRaw returns the cached raw results of input and the providers missing
+// from them. Degraded results are only used by fast searches, and stale ones
+// only when serveStale is set.
+func (fs *flightService) getRaw(ctx context.Context, input *domain.SearchRequest, serveStale bool) (hits []*domain.SearchResponse, missing []consts.ProviderKey) {
+	_, span := tracing.Start(ctx, "cache.lookup", trace.WithAttributes(attribute.String("cache", cacheProviderResults)))
+	defer func() {
+		span.SetAttributes(attribute.Int("hits", len(hits)), attribute.Int("missing", len(missing)))
+		span.End()
+	}()
+
+	keys := []consts.ProviderKey{""}
+	if fs.cachePolicy.RawPerProvider {
+		keys = consts.ProviderKeys
+	}
+	now := fs.clock.Now()
+	for _, key := range keys {
+		entry, err := fs.cache.GetEntry(input.ToProviderCacheKey(key))
+		hit, ok := entry.Value.(domain.SearchResponse)
+		if err != nil || !ok {
+			cacheLookups.WithLabelValues(cacheProviderResults, lookupMiss).Inc()
+			missing = append(missing, key)
+			continue
+		}
+		if hit.Metadata.Degraded() && input.Mode != consts.SearchModeFast {
+			cacheLookups.WithLabelValues(cacheProviderResults, lookupMiss).Inc()
+			missing = append(missing, key)
+			continue
+		}
+		if entry.Stale(now) {
+			if !serveStale {
+				cacheLookups.WithLabelValues(cacheProviderResults, lookupMiss).Inc()
+				missing = append(missing, key)
+				continue
+			}
+			cacheLookups.WithLabelValues(cacheProviderResults, lookupStale).Inc()
+			hit.Metadata.Stale = true
+		} else {
+			cacheLookups.WithLabelValues(cacheProviderResults, lookupHit).Inc()
+		}
+		// Cached metadata is shared with concurrent readers
+		hit.Metadata.Providers = slices.Clone(hit.Metadata.Providers)
+		for i := range hit.Metadata.Providers {
+			hit.Metadata.Providers[i].Cached = true
+		}
+		hits = append(hits, &hit)
+	}
+	if !fs.cachePolicy.RawPerProvider && len(missing) > 0 {
+		missing = consts.ProviderKeys
+	}
+	return hits, missing
+}
+
+// saveRawProviders caches the results of every provider that succeeded in
+// resp under its own key and TTL. Failed providers are queried again.
+func (fs *flightService) saveRawProviders(ctx context.Context, input *domain.SearchRequest, resp *domain.SearchResponse) {
+	for _, pm := range resp.Metadata.Providers {
+		if !pm.Succeeded {
+			continue
+		}
+		key := consts.ProviderKey(pm.Provider)
+		part := &domain.SearchResponse{
+			Flights: []domain.FlightInfo{},
+			Metadata: domain.SearchMetadata{
+				ProvidersQueried:   1,
+				ProvidersSucceeded: 1,
+				Complete:           true,
+				Providers:          []domain.ProviderMetadata{pm},
+			},
+		}
+		for _, f := range resp.Flights {
+			if f.ProviderKey == key {
+				part.Flights = append(part.Flights, f)
+			}
+		}
+		part.Metadata.TotalResults = len(part.Flights)
+
+		ttl := fs.routeTTL(input)
+		if seconds, ok := fs.cachePolicy.ProviderTTLSeconds[pm.Provider]; ok {
+			ttl = time.Duration(seconds) * time.Second
+		}
+		if ttl, ok := fs.limitTTL(ttl, part); ok {
+			fs.setCache(ctx, input.ToProviderCacheKey(key), part, ttl)
+		}
+	}
+}
+
+// combineResults joins the results of disjoint sets of providers.
+func combineResults(parts ...*domain.SearchResponse) *domain.SearchResponse {
+	resp := &domain.SearchResponse{Flights: []domain.FlightInfo{}}
+	meta := &resp.Metadata
+	for _, part := range parts {
+		resp.Flights = append(resp.Flights, part.Flights...)
+		meta.ProvidersQueried += part.Metadata.ProvidersQueried
+		meta.ProvidersSucceeded += part.Metadata.ProvidersSucceeded
+		meta.ProvidersFailed += part.Metadata.ProvidersFailed
+		meta.Stale = meta.Stale || part.Metadata.Stale
+		meta.DegradedProviders = append(meta.DegradedProviders, part.Metadata.DegradedProviders...)
+		meta.Providers = append(meta.Providers, part.Metadata.Providers...)
+	}
+	slices.Sort(meta.DegradedProviders)
+	slices.SortFunc(meta.Providers, func(a, b domain.ProviderMetadata) int {
+		return strings.Compare(a.Provider, b.Provider)
+	})
+	meta.Complete = !meta.Degraded()
+	meta.TotalResults = len(resp.Flights)
+	return resp
+}
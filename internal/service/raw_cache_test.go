@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func providerResults(keys ...consts.ProviderKey) *domain.SearchResponse {
+	resp := &domain.SearchResponse{Flights: []domain.FlightInfo{}}
+	for i, key := range keys {
+		resp.Flights = append(resp.Flights, domain.FlightInfo{
+			ID:          string(key),
+			ProviderKey: key,
+			Price:       domain.PriceInfo{Amount: 1000 * (i + 1)},
+			Duration:    domain.DurationInfo{TotalMinutes: 60},
+		})
+		resp.Metadata.Providers = append(resp.Metadata.Providers, domain.ProviderMetadata{Provider: string(key), Succeeded: true, Flights: 1})
+	}
+	resp.Metadata.ProvidersQueried = len(keys)
+	resp.Metadata.ProvidersSucceeded = len(keys)
+	resp.Metadata.Complete = true
+	return resp
+}
+
+func TestFlightService_SerchFlight_RawCache(t *testing.T) {
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	byPriceDesc := req
+	byPriceDesc.Sort = domain.SortOption{Key: consts.SortKeyPrice, Order: consts.SortOrderDesc}
+	cheap := req
+	cheap.Filters = []domain.SearchFilter{{Key: consts.FilterKeyMaxPrice, Value: 2500.0}}
+
+	newService := func(clk clock.Clock, policy config.CachePolicyConfig) (*flightService, *MockAirlineAggregator) {
+		mockProvider := new(MockAirlineAggregator)
+		return &flightService{
+			airlaneProvider: mockProvider,
+			cache:           cache.NewGoCacheWithClock(cache.CacheConfig{}, clk),
+			clock:           clk,
+			defaultTTL:      10 * time.Minute,
+			cachePolicy:     policy,
+		}, mockProvider
+	}
+
+	t.Run("Refinements_ReuseProviderResults", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		svc, mockProvider := newService(clk, config.CachePolicyConfig{RawEnabled: true, RawPerProvider: true})
+		mockProvider.On("SearchFlights", mock.Anything, req).Return(providerResults(consts.ProviderKeys...), nil).Once()
+
+		resp, err := svc.SerchFlight(context.Background(), &req)
+		assert.NoError(t, err)
+		assert.Equal(t, "airasia", resp.Flights[0].ID)
+		assert.False(t, resp.Metadata.Providers[0].Cached)
+
+		resp, err = svc.SerchFlight(context.Background(), &byPriceDesc)
+		assert.NoError(t, err)
+		assert.False(t, resp.Metadata.CacheHit)
+		assert.Equal(t, "lion", resp.Flights[0].ID)
+		assert.Equal(t, 4, resp.Metadata.ProvidersSucceeded)
+		assert.True(t, resp.Metadata.Complete)
+		assert.True(t, resp.Metadata.Providers[0].Cached)
+
+		resp, err = svc.SerchFlight(context.Background(), &cheap)
+		assert.NoError(t, err)
+		assert.Len(t, resp.Flights, 2)
+		mockProvider.AssertNumberOfCalls(t, "SearchFlights", 1)
+	})
+
+	t.Run("ExpiredProvider_QueriedAlone", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		svc, mockProvider := newService(clk, config.CachePolicyConfig{
+			RawEnabled:         true,
+			RawPerProvider:     true,
+			ProviderTTLSeconds: map[string]int{"airasia": 60},
+		})
+		mockProvider.On("SearchFlights", mock.Anything, req).Return(providerResults(consts.ProviderKeys...), nil).Once()
+		mockProvider.On("SearchProviders", mock.Anything, byPriceDesc, []consts.ProviderKey{consts.ProviderKeyAirAsia}).
+			Return(providerResults(consts.ProviderKeyAirAsia), nil).Once()
+
+		_, err := svc.SerchFlight(context.Background(), &req)
+		assert.NoError(t, err)
+		clk.Advance(61 * time.Second)
+
+		resp, err := svc.SerchFlight(context.Background(), &byPriceDesc)
+		assert.NoError(t, err)
+		assert.Len(t, resp.Flights, 4)
+		assert.Equal(t, 4, resp.Metadata.ProvidersSucceeded)
+		assert.False(t, resp.Metadata.Providers[0].Cached)
+		assert.True(t, resp.Metadata.Providers[1].Cached)
+		mockProvider.AssertExpectations(t)
+	})
+
+	t.Run("AllProviders_CachedTogether", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		svc, mockProvider := newService(clk, config.CachePolicyConfig{RawEnabled: true})
+		mockProvider.On("SearchFlights", mock.Anything, req).Return(providerResults(consts.ProviderKeys...), nil).Once()
+
+		_, err := svc.SerchFlight(context.Background(), &req)
+		assert.NoError(t, err)
+		resp, err := svc.SerchFlight(context.Background(), &byPriceDesc)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "lion", resp.Flights[0].ID)
+		assert.True(t, resp.Metadata.Providers[3].Cached)
+		mockProvider.AssertNumberOfCalls(t, "SearchFlights", 1)
+	})
+}
+
+func TestFlightService_RawCache_Redis(t *testing.T) {
+	srv := miniredis.RunT(t)
+	svc := &flightService{
+		cache: NewSearchCache(cache.CacheConfig{
+			Enabled: true,
+			Driver:  cache.DriverRedis,
+			Redis:   cache.RedisConfig{Addr: srv.Addr(), KeyPrefix: "bookcabin:"},
+		}, clock.New()),
+		clock:       clock.New(),
+		defaultTTL:  10 * time.Minute,
+		cachePolicy: config.CachePolicyConfig{RawEnabled: true, RawPerProvider: true},
+	}
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	fresh := providerResults(consts.ProviderKeyGarudaIndonesia, consts.ProviderKeyLionAir)
+	for i := range fresh.Flights {
+		fresh.Flights[i].SelectedFare = domain.FareOffer{Brand: consts.FareBrandValue, Refundable: true}
+		fresh.Flights[i].TotalTripDuration = 3600
+	}
+
+	svc.saveRawProviders(context.Background(), &req, fresh)
+	hits, missing := svc.getRaw(context.Background(), &req, false)
+
+	assert.ElementsMatch(t, []consts.ProviderKey{consts.ProviderKeyAirAsia, consts.ProviderKeyBatikAir}, missing)
+	if assert.Len(t, hits, 2) {
+		// The fields hidden from the API survive the round trip
+		for _, hit := range hits {
+			if assert.Len(t, hit.Flights, 1) {
+				f := hit.Flights[0]
+				assert.Equal(t, string(f.ProviderKey), f.ID)
+				assert.Equal(t, domain.FareOffer{Brand: consts.FareBrandValue, Refundable: true}, f.SelectedFare)
+				assert.Equal(t, int64(3600), f.TotalTripDuration)
+			}
+		}
+	}
+}
+
+func TestFlightService_RawCache_UnexpectedEntry(t *testing.T) {
+	svc := &flightService{
+		cache:       cache.NewGoCache(cache.CacheConfig{}),
+		clock:       clock.New(),
+		cachePolicy: config.CachePolicyConfig{RawEnabled: true, RawPerProvider: true},
+	}
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	svc.saveRawProviders(context.Background(), &req, providerResults(consts.ProviderKeyGarudaIndonesia))
+	assert.NoError(t, svc.cache.Set(req.ToProviderCacheKey(consts.ProviderKeyLionAir), "not a result"))
+
+	hits, missing := svc.getRaw(context.Background(), &req, false)
+
+	assert.Len(t, hits, 1)
+	assert.ElementsMatch(t, []consts.ProviderKey{consts.ProviderKeyAirAsia, consts.ProviderKeyBatikAir, consts.ProviderKeyLionAir}, missing)
+}
+
+func TestFlightService_RawCache_FetchAbandoned(t *testing.T) {
+	mockProvider := new(MockAirlineAggregator)
+	svc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewGoCache(cache.CacheConfig{}),
+		clock:           clock.New(),
+		cachePolicy:     config.CachePolicyConfig{RawEnabled: true, RawPerProvider: true},
+	}
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	// Every caller left while the providers were queried, cutting some short
+	ctx, cancel := context.WithCancel(context.Background())
+	partial := providerResults(consts.ProviderKeyGarudaIndonesia)
+	partial.Metadata.DegradedProviders = []string{"lion"}
+	mockProvider.On("SearchFlights", mock.Anything, req).Run(func(mock.Arguments) { cancel() }).Return(partial, nil)
+
+	_, err := svc.fetchRaw(ctx, &req, true)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	keys, err := svc.cache.Keys("")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"github.com/azcov/bookcabin_test/internal/catalog"
+)
+
+type ReferenceInterface interface {
+	ListAirlines(ctx context.Context) []catalog.Airline
+	ListAircraft(ctx context.Context) []catalog.Aircraft
+}
+
+type referenceService struct{}
+
+func NewReferenceService() ReferenceInterface {
+	return &referenceService{}
+}
+
+func (rs *referenceService) ListAirlines(ctx context.Context) []catalog.Airline {
+	return catalog.Airlines()
+}
+
+func (rs *referenceService) ListAircraft(ctx context.Context) []catalog.Aircraft {
+	return catalog.AircraftTypes()
+}
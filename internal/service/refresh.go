@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/logger"
+)
+
+// refreshJob re-queries the providers missing from the cached result under
+// key, or with revalidate all providers of the stale result under key.
+type refreshJob struct {
+	key        string
+	input      domain.SearchRequest
+	providers  []consts.ProviderKey
+	revalidate bool
+}
+
+// refresher queues refresh jobs for the workers. A key is queued at most
+// once until its refresh is done, however many searches hit it meanwhile.
+type refresher struct {
+	jobs chan refreshJob
+	// ctx is the context of the refreshes, cancelled when closing takes too
+	// long.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+	closed  bool
+}
+
+func newRefresher(queueSize int) *refresher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &refresher{
+		jobs:    make(chan refreshJob, max(queueSize, 1)),
+		ctx:     ctx,
+		cancel:  cancel,
+		pending: make(map[string]struct{}),
+	}
+}
+
+// start runs n workers calling work until the refresher is closed.
+func (r *refresher) start(n int, work func()) {
+	for range n {
+		r.workers.Add(1)
+		go func() {
+			defer r.workers.Done()
+			work()
+		}()
+	}
+}
+
+// schedule queues job unless its key is already pending, the queue is full or
+// the refresher is closed.
+func (r *refresher) schedule(job refreshJob) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return false
+	}
+	if _, ok := r.pending[job.key]; ok {
+		return false
+	}
+	select {
+	case r.jobs <- job:
+		r.pending[job.key] = struct{}{}
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *refresher) done(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, key)
+}
+
+// close stops accepting jobs and waits for the workers to finish the queued
+// ones. When ctx ends first the refreshes still running are cancelled, and
+// ctx.Err() is returned once the workers stopped.
+func (r *refresher) close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.jobs)
+	}
+	r.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		r.workers.Wait()
+		close(stopped)
+	}()
+	defer r.cancel()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		<-stopped
+		return ctx.Err()
+	}
+}
+
+// scheduleRefresh queues a background re-query of the providers missing from
+// a degraded result.
+func (fs *flightService) scheduleRefresh(ctx context.Context, key string, input domain.SearchRequest, meta domain.SearchMetadata) {
+	if fs.refresher == nil || !meta.Degraded() {
+		return
+	}
+	providers := make([]consts.ProviderKey, 0, len(meta.DegradedProviders))
+	for _, p := range meta.DegradedProviders {
+		providers = append(providers, consts.ProviderKey(p))
+	}
+	// The refresh is not bound by the client's wait
+	input.Mode = consts.SearchModeWaitAll
+	input.MaxWaitMs = 0
+	if fs.refresher.schedule(refreshJob{key: key, input: input, providers: providers}) {
+		logger.InfoContext(ctx, "Scheduled refresh of degraded result", "providers", providers)
+	}
+}
+
+func (fs *flightService) refreshWorker() {
+	for job := range fs.refresher.jobs {
+		ctx := logger.WithContext(fs.refresher.ctx, "search_key", strings.TrimPrefix(job.key, revalidatePrefix))
+		if job.revalidate {
+			fs.revalidate(ctx, job)
+		} else {
+			fs.refresh(ctx, job)
+		}
+		fs.refresher.done(job.key)
+	}
+}
+
+// refresh searches the providers of job and merges their flights into the
+// cached result, which is left alone if it expired meanwhile. The result is
+// read and written back under the write lock of its key, so that a result
+// saved meanwhile is merged into rather than overwritten.
+func (fs *flightService) refresh(ctx context.Context, job refreshJob) {
+	partial, err := fs.airlaneProvider.SearchProviders(ctx, job.input, job.providers)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error refreshing degraded result", "err", err)
+		return
+	}
+	if partial.Metadata.ProvidersSucceeded == 0 {
+		return
+	}
+	if fs.cachePolicy.RawEnabled && fs.cachePolicy.RawPerProvider {
+		fs.saveRawProviders(ctx, &job.input, partial)
+	}
+
+	unlock := fs.writes.lock(job.key)
+	defer unlock()
+	// A stale result is revalidated as a whole instead
+	entry, err := fs.cache.GetEntry(job.key)
+	if err != nil || entry.Stale(fs.clock.Now()) {
+		return
+	}
+	cached, ok := entry.Value.(domain.SearchResponse)
+	if !ok {
+		return
+	}
+	merged, ok := fs.mergeResults(cached, partial, &job.input)
+	if !ok {
+		return
+	}
+	fs.saveToCacheLocked(ctx, job.key, &job.input, &merged)
+}
+
+// mergeResults adds the flights of the providers that succeeded in partial
+// and are still degraded in cached, which may have been replaced since the
+// refresh was scheduled. It reports false when there is none. Slices are
+// copied since cached is shared with concurrent readers.
+func (fs *flightService) mergeResults(cached domain.SearchResponse, partial *domain.SearchResponse, input *domain.SearchRequest) (domain.SearchResponse, bool) {
+	var refreshed []string
+	for _, pm := range partial.Metadata.Providers {
+		if pm.Succeeded && slices.Contains(cached.Metadata.DegradedProviders, pm.Provider) {
+			refreshed = append(refreshed, pm.Provider)
+		}
+	}
+	if len(refreshed) == 0 {
+		return cached, false
+	}
+	flights := slices.DeleteFunc(slices.Clone(partial.Flights), func(f domain.FlightInfo) bool {
+		return !slices.Contains(refreshed, string(f.ProviderKey))
+	})
+
+	merged := cached
+	merged.Flights = slices.Concat(cached.Flights, fs.rankFlights(flights, input))
+	fs.sortFlights(merged.Flights, input.Sort)
+
+	meta := &merged.Metadata
+	meta.Providers = slices.Clone(cached.Metadata.Providers)
+	meta.DegradedProviders = slices.Clone(cached.Metadata.DegradedProviders)
+	for _, pm := range partial.Metadata.Providers {
+		if !slices.Contains(refreshed, pm.Provider) {
+			continue
+		}
+		meta.ProvidersSucceeded++
+		meta.ProvidersFailed--
+		meta.DegradedProviders = slices.DeleteFunc(meta.DegradedProviders, func(p string) bool { return p == pm.Provider })
+		meta.Providers = slices.DeleteFunc(meta.Providers, func(m domain.ProviderMetadata) bool { return m.Provider == pm.Provider })
+		meta.Providers = append(meta.Providers, pm)
+	}
+	slices.SortFunc(meta.Providers, func(a, b domain.ProviderMetadata) int {
+		return strings.Compare(a.Provider, b.Provider)
+	})
+	if len(meta.DegradedProviders) == 0 {
+		meta.DegradedProviders = nil
+	}
+	meta.Complete = !meta.Degraded()
+	meta.TotalResults = len(merged.Flights)
+	return merged, true
+}
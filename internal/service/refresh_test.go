@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRefresher_Schedule(t *testing.T) {
+	r := newRefresher(2)
+
+	assert.True(t, r.schedule(refreshJob{key: "a"}))
+	// Deduplicated while pending
+	assert.False(t, r.schedule(refreshJob{key: "a"}))
+	assert.True(t, r.schedule(refreshJob{key: "b"}))
+	// Queue is full
+	assert.False(t, r.schedule(refreshJob{key: "c"}))
+
+	<-r.jobs
+	r.done("a")
+	assert.True(t, r.schedule(refreshJob{key: "a"}))
+}
+
+func TestFlightService_Close(t *testing.T) {
+	cfg := config.Config{
+		Cache:  cache.CacheConfig{Enabled: true},
+		Search: config.SearchConfig{RefreshWorkers: 2, RefreshQueueSize: 10},
+	}
+	job := refreshJob{key: "key", input: domain.SearchRequest{Origin: "CGK"}, providers: []consts.ProviderKey{consts.ProviderKeyLionAir}}
+
+	t.Run("QueuedRefreshesDrained", func(t *testing.T) {
+		mockProvider := new(MockAirlineAggregator)
+		svc := NewFlightService(cfg, mockProvider, clock.New()).(*flightService)
+		mockProvider.On("SearchProviders", mock.Anything, job.input, job.providers).Return(&domain.SearchResponse{}, nil).Once()
+
+		assert.True(t, svc.refresher.schedule(job))
+		assert.NoError(t, svc.Close(context.Background()))
+		mockProvider.AssertExpectations(t)
+		// Closed for good
+		assert.False(t, svc.refresher.schedule(refreshJob{key: "other"}))
+		assert.NoError(t, svc.Close(context.Background()))
+	})
+
+	t.Run("RunningRefreshCancelledAtDeadline", func(t *testing.T) {
+		mockProvider := new(MockAirlineAggregator)
+		svc := NewFlightService(cfg, mockProvider, clock.New()).(*flightService)
+		started := make(chan struct{})
+		mockProvider.On("SearchProviders", mock.Anything, job.input, job.providers).
+			Run(func(args mock.Arguments) {
+				close(started)
+				<-args.Get(0).(context.Context).Done()
+			}).
+			Return(nil, context.Canceled).Once()
+
+		assert.True(t, svc.refresher.schedule(job))
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, svc.Close(ctx), context.DeadlineExceeded)
+		mockProvider.AssertExpectations(t)
+	})
+}
+
+func TestFlightService_RefreshDegraded(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	mockProvider := new(MockAirlineAggregator)
+	svc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewGoCacheWithClock(cache.CacheConfig{}, clk),
+		clock:           clk,
+		search:          config.SearchConfig{CacheDegraded: true, DegradedCacheTTLSeconds: 10},
+		refresher:       newRefresher(10),
+	}
+
+	req := domain.SearchRequest{Origin: "CGK", Mode: consts.SearchModeFast, MaxWaitMs: 300, Sort: domain.SortOption{Key: consts.SortKeyPrice}}
+	mockProvider.On("SearchFlights", mock.Anything, req).Return(&domain.SearchResponse{
+		Flights: []domain.FlightInfo{{ID: "expensive", ProviderKey: consts.ProviderKeyGarudaIndonesia, Price: domain.PriceInfo{Amount: 2000}}},
+		Metadata: domain.SearchMetadata{
+			ProvidersQueried:   2,
+			ProvidersSucceeded: 1,
+			ProvidersFailed:    1,
+			DegradedProviders:  []string{"lion"},
+			Providers: []domain.ProviderMetadata{
+				{Provider: "garuda", Succeeded: true, Flights: 1},
+				{Provider: "lion", ErrorCode: "timeout"},
+			},
+		},
+	}, nil).Once()
+
+	resp, err := svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.False(t, resp.Metadata.Complete)
+
+	// A second search finds the refresh already pending
+	_, err = svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.Len(t, svc.refresher.jobs, 1)
+
+	job := <-svc.refresher.jobs
+	assert.Equal(t, []consts.ProviderKey{consts.ProviderKeyLionAir}, job.providers)
+	assert.Equal(t, consts.SearchModeWaitAll, job.input.Mode)
+
+	mockProvider.On("SearchProviders", mock.Anything, job.input, job.providers).Return(&domain.SearchResponse{
+		Flights: []domain.FlightInfo{{ID: "cheap", ProviderKey: consts.ProviderKeyLionAir, Price: domain.PriceInfo{Amount: 1000}}},
+		Metadata: domain.SearchMetadata{
+			ProvidersQueried:   1,
+			ProvidersSucceeded: 1,
+			Providers:          []domain.ProviderMetadata{{Provider: "lion", Succeeded: true, Flights: 1}},
+		},
+	}, nil)
+	svc.refresh(context.Background(), job)
+	svc.refresher.done(job.key)
+
+	resp, err = svc.SerchFlight(context.Background(), &req)
+	assert.NoError(t, err)
+	assert.True(t, resp.Metadata.CacheHit)
+	assert.True(t, resp.Metadata.Complete)
+	assert.Empty(t, resp.Metadata.DegradedProviders)
+	assert.Equal(t, 2, resp.Metadata.ProvidersSucceeded)
+	assert.Equal(t, 0, resp.Metadata.ProvidersFailed)
+	assert.Equal(t, 2, resp.Metadata.TotalResults)
+	assert.Equal(t, "cheap", resp.Flights[0].ID)
+	assert.True(t, resp.Metadata.Providers[1].Succeeded)
+	// Complete now, nothing left to refresh
+	assert.Len(t, svc.refresher.jobs, 0)
+	mockProvider.AssertNumberOfCalls(t, "SearchFlights", 1)
+}
+
+func TestFlightService_RefreshDegraded_EntryReplaced(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	mockProvider := new(MockAirlineAggregator)
+	svc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewGoCacheWithClock(cache.CacheConfig{}, clk),
+		clock:           clk,
+		search:          config.SearchConfig{CacheDegraded: true, DegradedCacheTTLSeconds: 10},
+		refresher:       newRefresher(10),
+	}
+	garuda := domain.FlightInfo{ID: "garuda", ProviderKey: consts.ProviderKeyGarudaIndonesia, Price: domain.PriceInfo{Amount: 2000}}
+	lion := domain.FlightInfo{ID: "lion", ProviderKey: consts.ProviderKeyLionAir, Price: domain.PriceInfo{Amount: 1000}}
+
+	fast := domain.SearchRequest{Origin: "CGK", Mode: consts.SearchModeFast, MaxWaitMs: 300}
+	mockProvider.On("SearchFlights", mock.Anything, fast).Return(&domain.SearchResponse{
+		Flights: []domain.FlightInfo{garuda},
+		Metadata: domain.SearchMetadata{
+			ProvidersQueried:   2,
+			ProvidersSucceeded: 1,
+			ProvidersFailed:    1,
+			DegradedProviders:  []string{"lion"},
+			Providers: []domain.ProviderMetadata{
+				{Provider: "garuda", Succeeded: true, Flights: 1},
+				{Provider: "lion", ErrorCode: "timeout"},
+			},
+		},
+	}, nil).Once()
+	_, err := svc.SerchFlight(context.Background(), &fast)
+	assert.NoError(t, err)
+	job := <-svc.refresher.jobs
+
+	// A search waiting for all providers replaces the degraded result under
+	// the same key before the refresh runs
+	waitAll := domain.SearchRequest{Origin: "CGK", Mode: consts.SearchModeWaitAll}
+	mockProvider.On("SearchFlights", mock.Anything, waitAll).Return(&domain.SearchResponse{
+		Flights: []domain.FlightInfo{garuda, lion},
+		Metadata: domain.SearchMetadata{
+			ProvidersQueried:   2,
+			ProvidersSucceeded: 2,
+			Complete:           true,
+			Providers: []domain.ProviderMetadata{
+				{Provider: "garuda", Succeeded: true, Flights: 1},
+				{Provider: "lion", Succeeded: true, Flights: 1},
+			},
+		},
+	}, nil).Once()
+	_, err = svc.SerchFlight(context.Background(), &waitAll)
+	assert.NoError(t, err)
+	replaced, err := svc.cache.GetEntry(job.key)
+	assert.NoError(t, err)
+
+	clk.Advance(time.Second)
+	mockProvider.On("SearchProviders", mock.Anything, job.input, job.providers).Return(&domain.SearchResponse{
+		Flights: []domain.FlightInfo{lion},
+		Metadata: domain.SearchMetadata{
+			ProvidersQueried:   1,
+			ProvidersSucceeded: 1,
+			Providers:          []domain.ProviderMetadata{{Provider: "lion", Succeeded: true, Flights: 1}},
+		},
+	}, nil)
+	svc.refresh(context.Background(), job)
+
+	// Lion is no longer degraded, the complete result is left alone
+	entry, err := svc.cache.GetEntry(job.key)
+	assert.NoError(t, err)
+	assert.Equal(t, replaced.StoredAt, entry.StoredAt)
+	result := entry.Value.(domain.SearchResponse)
+	assert.Len(t, result.Flights, 2)
+	assert.Equal(t, 2, result.Metadata.ProvidersSucceeded)
+	assert.Equal(t, 0, result.Metadata.ProvidersFailed)
+	assert.True(t, result.Metadata.Complete)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/logger"
+)
+
+// revalidatePrefix keeps revalidations apart from the refreshes of degraded
+// results in the refresher's pending keys.
+const revalidatePrefix = "revalidate:"
+
+// scheduleRevalidate queues a background search replacing the stale result
+// cached under key.
+func (fs *flightService) scheduleRevalidate(ctx context.Context, key string, input domain.SearchRequest) {
+	if fs.refresher == nil {
+		return
+	}
+	// The revalidation is not bound by the client's wait
+	input.Mode = consts.SearchModeWaitAll
+	input.MaxWaitMs = 0
+	if fs.refresher.schedule(refreshJob{key: revalidatePrefix + key, input: input, revalidate: true}) {
+		logger.InfoContext(ctx, "Scheduled revalidation of stale result")
+	}
+}
+
+// revalidate searches again for the stale result of job, refreshing the stale
+// raw results it was built from, and caches it. Revalidations of searches
+// sharing their raw results share one fetch, so only the first one queries
+// the providers.
+func (fs *flightService) revalidate(ctx context.Context, job refreshJob) {
+	input := job.input
+	key := input.ToProviderCacheKey("") + ";revalidate"
+	raw, _, err := fs.inflight.do(ctx, key, func(ctx context.Context) (*domain.SearchResponse, error) {
+		return fs.fetchRaw(ctx, &input, false)
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error revalidating stale result", "err", err)
+		return
+	}
+	if raw.Metadata.ProvidersSucceeded == 0 {
+		return
+	}
+
+	result := *raw
+	result.Flights = fs.rankFlights(raw.Flights, &input)
+	result.Metadata.TotalResults = len(result.Flights)
+	result.SearchCriteria = input
+	fs.saveToCache(ctx, strings.TrimPrefix(job.key, revalidatePrefix), &input, &result)
+}
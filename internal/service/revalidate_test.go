@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFlightService_SerchFlight_StaleWhileRevalidate(t *testing.T) {
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+
+	newService := func(clk clock.Clock, policy config.CachePolicyConfig) (*flightService, *MockAirlineAggregator) {
+		mockProvider := new(MockAirlineAggregator)
+		return &flightService{
+			airlaneProvider: mockProvider,
+			cache:           cache.NewGoCacheWithClock(cache.CacheConfig{}, clk),
+			clock:           clk,
+			refresher:       newRefresher(10),
+			defaultTTL:      10 * time.Minute,
+			cachePolicy:     policy,
+		}, mockProvider
+	}
+
+	t.Run("StaleResult_ServedAndRevalidated", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		svc, mockProvider := newService(clk, config.CachePolicyConfig{SoftTTLPercent: 50})
+		mockProvider.On("SearchFlights", mock.Anything, req).Return(providerResults(consts.ProviderKeyGarudaIndonesia), nil).Once()
+
+		resp, err := svc.SerchFlight(context.Background(), &req)
+		assert.NoError(t, err)
+		assert.False(t, resp.Metadata.Stale)
+
+		// Past the soft TTL of 5 minutes
+		clk.Advance(6 * time.Minute)
+		input := req
+		resp, err = svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+		assert.True(t, resp.Metadata.CacheHit)
+		assert.True(t, resp.Metadata.Stale)
+		assert.Len(t, resp.Flights, 1)
+
+		// A second search finds the revalidation already pending
+		input = req
+		_, err = svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+		assert.Len(t, svc.refresher.jobs, 1)
+
+		job := <-svc.refresher.jobs
+		assert.True(t, job.revalidate)
+		assert.Equal(t, consts.SearchModeWaitAll, job.input.Mode)
+		mockProvider.On("SearchFlights", mock.Anything, job.input).
+			Return(providerResults(consts.ProviderKeyGarudaIndonesia, consts.ProviderKeyLionAir), nil).Once()
+		svc.revalidate(context.Background(), job)
+		svc.refresher.done(job.key)
+
+		input = req
+		resp, err = svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+		assert.True(t, resp.Metadata.CacheHit)
+		assert.False(t, resp.Metadata.Stale)
+		assert.Len(t, resp.Flights, 2)
+		assert.Len(t, svc.refresher.jobs, 0)
+		mockProvider.AssertExpectations(t)
+	})
+
+	t.Run("ExpiredResult_NotServed", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		svc, mockProvider := newService(clk, config.CachePolicyConfig{SoftTTLPercent: 50})
+		mockProvider.On("SearchFlights", mock.Anything, req).Return(providerResults(consts.ProviderKeyGarudaIndonesia), nil).Twice()
+
+		input := req
+		_, err := svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+
+		// Past the hard TTL of 10 minutes
+		clk.Advance(10 * time.Minute)
+		input = req
+		resp, err := svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+		assert.False(t, resp.Metadata.CacheHit)
+		assert.False(t, resp.Metadata.Stale)
+		assert.Len(t, svc.refresher.jobs, 0)
+		mockProvider.AssertExpectations(t)
+	})
+
+	t.Run("StaleProvider_RevalidatedAlone", func(t *testing.T) {
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		svc, mockProvider := newService(clk, config.CachePolicyConfig{
+			RawEnabled:         true,
+			RawPerProvider:     true,
+			ProviderTTLSeconds: map[string]int{"airasia": 60},
+			SoftTTLPercent:     50,
+		})
+		mockProvider.On("SearchFlights", mock.Anything, req).Return(providerResults(consts.ProviderKeys...), nil).Once()
+
+		input := req
+		_, err := svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+
+		// Only airasia is past its soft TTL of 30 seconds; a refined search
+		// misses the result cache and is built from the raw results
+		clk.Advance(40 * time.Second)
+		byPriceDesc := req
+		byPriceDesc.Sort = domain.SortOption{Key: consts.SortKeyPrice, Order: consts.SortOrderDesc}
+		input = byPriceDesc
+		resp, err := svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+		assert.False(t, resp.Metadata.CacheHit)
+		assert.True(t, resp.Metadata.Stale)
+		assert.Len(t, resp.Flights, len(consts.ProviderKeys))
+
+		job := <-svc.refresher.jobs
+		mockProvider.On("SearchProviders", mock.Anything, job.input, []consts.ProviderKey{consts.ProviderKeyAirAsia}).
+			Return(providerResults(consts.ProviderKeyAirAsia), nil).Once()
+		svc.revalidate(context.Background(), job)
+		svc.refresher.done(job.key)
+
+		input = byPriceDesc
+		resp, err = svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+		assert.True(t, resp.Metadata.CacheHit)
+		assert.False(t, resp.Metadata.Stale)
+		mockProvider.AssertExpectations(t)
+	})
+}
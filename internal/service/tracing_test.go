@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/testutil"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestFlightService_SerchFlight_Spans(t *testing.T) {
+	sr, restore := testutil.NewRecorder()
+	defer restore()
+
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	mockProvider := new(MockAirlineAggregator)
+	svc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewGoCacheWithClock(cache.CacheConfig{}, clk),
+		clock:           clk,
+		cachePolicy:     config.CachePolicyConfig{RawEnabled: true},
+	}
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	mockProvider.On("SearchFlights", mock.Anything, req).Return(providerResults(consts.ProviderKeys...), nil).Once()
+
+	for range 2 {
+		input := req
+		_, err := svc.SerchFlight(context.Background(), &input)
+		assert.NoError(t, err)
+	}
+
+	searches := testutil.Ended(sr, "flightService.SerchFlight")
+	if !assert.Len(t, searches, 2) {
+		return
+	}
+	assert.Contains(t, searches[0].Attributes(), attribute.Bool("cache_hit", false))
+	assert.Contains(t, searches[0].Attributes(), attribute.String("origin", "CGK"))
+	assert.Contains(t, searches[1].Attributes(), attribute.Bool("cache_hit", true))
+
+	var lookups []attribute.Set
+	for _, span := range testutil.Ended(sr, "cache.lookup") {
+		assert.Equal(t, span.SpanContext().TraceID(), span.Parent().TraceID())
+		lookups = append(lookups, attribute.NewSet(span.Attributes()...))
+	}
+	// The miss looks up the raw results too, the hit does not
+	assert.Equal(t, []attribute.Set{
+		attribute.NewSet(attribute.String("cache", cacheResults), attribute.String("result", lookupMiss)),
+		attribute.NewSet(attribute.String("cache", cacheProviderResults), attribute.Int("hits", 0), attribute.Int("missing", len(consts.ProviderKeys))),
+		attribute.NewSet(attribute.String("cache", cacheResults), attribute.String("result", lookupHit)),
+	}, lookups)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/provider"
+	"github.com/azcov/bookcabin_test/internal/util"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/azcov/bookcabin_test/pkg/logger"
+)
+
+type WarmupInterface interface {
+	// Start runs the warm-up every interval until ctx ends. It does nothing
+	// when the warm-up is disabled.
+	Start(ctx context.Context)
+	Stats() domain.WarmupStats
+}
+
+type warmer struct {
+	cfg        config.WarmupConfig
+	flightSvc  FlightInterface
+	aggregator provider.AirlineAggregator
+	clock      clock.Clock
+
+	mu    sync.Mutex
+	stats domain.WarmupStats
+}
+
+// NewWarmer warms up the cache of flightSvc by searching the popular routes
+// of cfg through it, so the results are cached like those of client searches.
+// aggregator reports the providers' rate limit quota.
+func NewWarmer(cfg config.WarmupConfig, flightSvc FlightInterface, aggregator provider.AirlineAggregator, clk clock.Clock) WarmupInterface {
+	return &warmer{cfg: cfg, flightSvc: flightSvc, aggregator: aggregator, clock: clk}
+}
+
+func (w *warmer) Start(ctx context.Context) {
+	if !w.cfg.Enabled || len(w.cfg.Routes) == 0 || w.cfg.Days <= 0 {
+		return
+	}
+	interval := time.Duration(max(w.cfg.IntervalMinutes, 1)) * time.Minute
+	logger.Info("Starting cache warm-up", "routes", w.cfg.Routes, "days", w.cfg.Days, "interval", interval.String())
+	go func() {
+		for {
+			w.run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.clock.After(interval):
+			}
+		}
+	}()
+}
+
+func (w *warmer) Stats() domain.WarmupStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// run searches every route for every day once. Searches are sent one at a
+// time so that the warm-up never competes with client searches for long.
+func (w *warmer) run(ctx context.Context) {
+	start := w.clock.Now()
+	var run domain.WarmupStats
+	for _, route := range w.cfg.Routes {
+		origin, destination, ok := strings.Cut(strings.TrimSpace(route), "-")
+		if !ok || origin == "" || destination == "" {
+			logger.Warn("Skipping invalid warm-up route", "route", route)
+			continue
+		}
+		for day := range w.cfg.Days {
+			if ctx.Err() != nil {
+				return
+			}
+			if !w.hasQuota() {
+				run.Skipped++
+				warmupSearches.WithLabelValues(warmupSkipped).Inc()
+				continue
+			}
+			req := domain.SearchRequest{
+				Origin:        origin,
+				Destination:   destination,
+				DepartureDate: util.AirportLocalDate(start.AddDate(0, 0, day), origin),
+				Passengers:    domain.PassengerCount{Adults: 1},
+				CabinClass:    consts.CabinClassEconomy,
+				Mode:          consts.SearchModeWaitAll,
+			}
+			resp, err := w.flightSvc.SerchFlight(ctx, &req)
+			switch {
+			case err != nil:
+				run.Failed++
+				warmupSearches.WithLabelValues(warmupFailed).Inc()
+				logger.Warn("Warm-up search failed", "route", route, "departureDate", req.DepartureDate, "err", err)
+			case resp.Metadata.CacheHit:
+				run.CacheHits++
+				warmupSearches.WithLabelValues(warmupCacheHit).Inc()
+			default:
+				run.Fetched++
+				warmupSearches.WithLabelValues(warmupFetched).Inc()
+			}
+		}
+	}
+
+	elapsed := w.clock.Since(start)
+	warmupRuns.Inc()
+	logger.Info("Cache warm-up done", "fetched", run.Fetched, "cacheHits", run.CacheHits, "failed", run.Failed, "skipped", run.Skipped, "duration", elapsed.String())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stats.Runs++
+	w.stats.Fetched += run.Fetched
+	w.stats.CacheHits += run.CacheHits
+	w.stats.Failed += run.Failed
+	w.stats.Skipped += run.Skipped
+	w.stats.LastRunAt = &start
+	w.stats.LastRunMs = elapsed.Milliseconds()
+}
+
+// hasQuota reports whether every provider has the configured quota left.
+func (w *warmer) hasQuota() bool {
+	for _, key := range consts.ProviderKeys {
+		if w.aggregator.Quota(key) < float64(w.cfg.MinProviderQuota) {
+			return false
+		}
+	}
+	return true
+}
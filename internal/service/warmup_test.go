@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/internal/config"
+	"github.com/azcov/bookcabin_test/internal/consts"
+	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWarmer_Run(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 20, 0, 0, 0, time.UTC))
+	mockProvider := new(MockAirlineAggregator)
+	flightSvc := &flightService{
+		airlaneProvider: mockProvider,
+		cache:           cache.NewGoCacheWithClock(cache.CacheConfig{}, clk),
+		clock:           clk,
+	}
+	cfg := config.WarmupConfig{Enabled: true, Routes: []string{"CGK-DPS", "invalid"}, Days: 2, IntervalMinutes: 1, MinProviderQuota: 50}
+	w := NewWarmer(cfg, flightSvc, mockProvider, clk).(*warmer)
+
+	mockProvider.On("Quota", mock.Anything).Return(100.0)
+	mockProvider.On("SearchFlights", mock.Anything, mock.Anything).Return(providerResults(consts.ProviderKeyGarudaIndonesia), nil).Twice()
+
+	w.run(context.Background())
+	stats := w.Stats()
+	assert.Equal(t, uint64(1), stats.Runs)
+	assert.Equal(t, uint64(2), stats.Fetched)
+	// Departure dates are local to the origin, already the next day in Jakarta
+	var dates []string
+	for _, call := range mockProvider.Calls {
+		if call.Method == "SearchFlights" {
+			req := call.Arguments.Get(1).(domain.SearchRequest)
+			dates = append(dates, req.DepartureDate)
+			assert.Equal(t, consts.CabinClassEconomy, req.CabinClass)
+		}
+	}
+	assert.Equal(t, []string{"2025-12-16", "2025-12-17"}, dates)
+
+	// Cached results are not fetched again
+	w.run(context.Background())
+	stats = w.Stats()
+	assert.Equal(t, uint64(2), stats.CacheHits)
+	mockProvider.AssertNumberOfCalls(t, "SearchFlights", 2)
+}
+
+func TestWarmer_RespectsQuota(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	mockProvider := new(MockAirlineAggregator)
+	flightSvc := &flightService{airlaneProvider: mockProvider, cache: cache.NewNoop(), clock: clk}
+	cfg := config.WarmupConfig{Enabled: true, Routes: []string{"CGK-DPS"}, Days: 3, MinProviderQuota: 50}
+	w := NewWarmer(cfg, flightSvc, mockProvider, clk).(*warmer)
+
+	mockProvider.On("Quota", consts.ProviderKeyLionAir).Return(10.0)
+	mockProvider.On("Quota", mock.Anything).Return(100.0)
+
+	w.run(context.Background())
+
+	assert.Equal(t, uint64(3), w.Stats().Skipped)
+	mockProvider.AssertNotCalled(t, "SearchFlights", mock.Anything, mock.Anything)
+}
+
+func TestWarmer_Start(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	mockProvider := new(MockAirlineAggregator)
+	flightSvc := &flightService{airlaneProvider: mockProvider, cache: cache.NewNoop(), clock: clk}
+	cfg := config.WarmupConfig{Enabled: true, Routes: []string{"CGK-DPS"}, Days: 1, IntervalMinutes: 5, MinProviderQuota: 50}
+	w := NewWarmer(cfg, flightSvc, mockProvider, clk)
+
+	mockProvider.On("Quota", mock.Anything).Return(100.0)
+	mockProvider.On("SearchFlights", mock.Anything, mock.Anything).Return(providerResults(consts.ProviderKeyGarudaIndonesia), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	// The first run starts right away, the next one after the interval
+	clk.BlockUntil(1)
+	assert.Equal(t, uint64(1), w.Stats().Runs)
+	clk.Advance(5 * time.Minute)
+	clk.BlockUntil(1)
+	assert.Eventually(t, func() bool { return w.Stats().Runs == 2 }, time.Second, time.Millisecond)
+	mockProvider.AssertNumberOfCalls(t, "SearchFlights", 2)
+}
+
+func TestWarmer_StartDisabled(t *testing.T) {
+	mockProvider := new(MockAirlineAggregator)
+	w := NewWarmer(config.WarmupConfig{Routes: []string{"CGK-DPS"}, Days: 1}, nil, mockProvider, clock.New())
+
+	w.Start(context.Background())
+
+	assert.Equal(t, domain.WarmupStats{}, w.Stats())
+}
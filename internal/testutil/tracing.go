@@ -0,0 +1,28 @@
+// Package testutil holds helpers shared by the tests of several packages.
+package testutil
+
+import (
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// NewRecorder installs a global tracer provider recording every span, for
+// tests. restore reinstalls the previous provider.
+func NewRecorder() (sr *tracetest.SpanRecorder, restore func()) {
+	prev := otel.GetTracerProvider()
+	sr = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	return sr, func() { otel.SetTracerProvider(prev) }
+}
+
+// Ended returns the spans named name that sr saw end.
+func Ended(sr *tracetest.SpanRecorder, name string) []sdktrace.ReadOnlySpan {
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range sr.Ended() {
+		if span.Name() == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
@@ -6,18 +6,23 @@ import (
 	"github.com/azcov/bookcabin_test/pkg/httpz"
 	"github.com/gin-gonic/gin"
 
+	"github.com/azcov/bookcabin_test/internal/chaos"
+	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
 	"github.com/azcov/bookcabin_test/internal/service"
 )
 
 // Handler groups dependencies for API handlers
 type Handler struct {
-	FlightSvc service.FlightInterface
+	FlightSvc    service.FlightInterface
+	ReferenceSvc service.ReferenceInterface
+	ChaosSvc     service.ChaosInterface
+	CacheSvc     service.CacheAdminInterface
 }
 
 // NewHandler returns a new API handler instance
-func NewHandler(fsvc service.FlightInterface) *Handler {
-	return &Handler{FlightSvc: fsvc}
+func NewHandler(fsvc service.FlightInterface, rsvc service.ReferenceInterface, csvc service.ChaosInterface, casvc service.CacheAdminInterface) *Handler {
+	return &Handler{FlightSvc: fsvc, ReferenceSvc: rsvc, ChaosSvc: csvc, CacheSvc: casvc}
 }
 
 // SearchFlights handles POST /v1/flights/search
@@ -28,6 +33,10 @@ func (h *Handler) SearchFlights(c *gin.Context) {
 		httpz.JSONResponse(c, nil, eresp)
 		return
 	}
+	if err := req.Validate(); err != nil {
+		httpz.JSONResponse(c, nil, err)
+		return
+	}
 
 	// call service
 	resp, err := h.FlightSvc.SerchFlight(c.Request.Context(), &req)
@@ -38,3 +47,81 @@ func (h *Handler) SearchFlights(c *gin.Context) {
 
 	httpz.JSONResponse(c, resp, nil)
 }
+
+// ListAirlines handles GET /v1/reference/airlines
+func (h *Handler) ListAirlines(c *gin.Context) {
+	airlines := h.ReferenceSvc.ListAirlines(c.Request.Context())
+	httpz.JSONResponse(c, gin.H{"airlines": airlines}, nil)
+}
+
+// ListAircraft handles GET /v1/reference/aircraft
+func (h *Handler) ListAircraft(c *gin.Context) {
+	aircraft := h.ReferenceSvc.ListAircraft(c.Request.Context())
+	httpz.JSONResponse(c, gin.H{"aircraft": aircraft}, nil)
+}
+
+// GetChaos handles GET /v1/admin/chaos
+func (h *Handler) GetChaos(c *gin.Context) {
+	httpz.JSONResponse(c, h.ChaosSvc.GetChaosConfig(c.Request.Context()), nil)
+}
+
+// UpdateChaos handles PUT /v1/admin/chaos
+func (h *Handler) UpdateChaos(c *gin.Context) {
+	var req chaos.Config
+	if err := c.ShouldBindJSON(&req); err != nil {
+		eresp := httpz.NewErrorResponse(http.StatusBadRequest, "invalid_request", err.Error(), nil)
+		httpz.JSONResponse(c, nil, eresp)
+		return
+	}
+
+	resp, err := h.ChaosSvc.UpdateChaosConfig(c.Request.Context(), req)
+	httpz.JSONResponse(c, resp, err)
+}
+
+// UpdateProviderChaos handles PUT /v1/admin/chaos/:provider
+func (h *Handler) UpdateProviderChaos(c *gin.Context) {
+	var req chaos.ProviderConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		eresp := httpz.NewErrorResponse(http.StatusBadRequest, "invalid_request", err.Error(), nil)
+		httpz.JSONResponse(c, nil, eresp)
+		return
+	}
+
+	resp, err := h.ChaosSvc.UpdateProviderChaos(c.Request.Context(), consts.ProviderKey(c.Param("provider")), req)
+	httpz.JSONResponse(c, resp, err)
+}
+
+// ListCacheKeys handles GET /v1/admin/cache/keys?prefix=
+func (h *Handler) ListCacheKeys(c *gin.Context) {
+	keys, err := h.CacheSvc.ListCacheKeys(c.Request.Context(), c.Query("prefix"))
+	if err != nil {
+		httpz.JSONResponse(c, nil, err)
+		return
+	}
+	httpz.JSONResponse(c, gin.H{"keys": keys, "count": len(keys)}, nil)
+}
+
+// GetCacheEntry handles GET /v1/admin/cache/entry?key=
+func (h *Handler) GetCacheEntry(c *gin.Context) {
+	resp, err := h.CacheSvc.GetCacheEntry(c.Request.Context(), c.Query("key"))
+	httpz.JSONResponse(c, resp, err)
+}
+
+// PurgeCache handles DELETE /v1/admin/cache?origin=&destination=&provider=
+func (h *Handler) PurgeCache(c *gin.Context) {
+	var req domain.CachePurgeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		eresp := httpz.NewErrorResponse(http.StatusBadRequest, "invalid_request", err.Error(), nil)
+		httpz.JSONResponse(c, nil, eresp)
+		return
+	}
+
+	resp, err := h.CacheSvc.PurgeCache(c.Request.Context(), req)
+	httpz.JSONResponse(c, resp, err)
+}
+
+// GetCacheStats handles GET /v1/admin/cache/stats
+func (h *Handler) GetCacheStats(c *gin.Context) {
+	resp, err := h.CacheSvc.GetCacheStats(c.Request.Context())
+	httpz.JSONResponse(c, resp, err)
+}
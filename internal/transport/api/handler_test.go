@@ -9,7 +9,14 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/azcov/bookcabin_test/internal/catalog"
+	"github.com/azcov/bookcabin_test/internal/chaos"
+	"github.com/azcov/bookcabin_test/internal/consts"
 	"github.com/azcov/bookcabin_test/internal/domain"
+	"github.com/azcov/bookcabin_test/internal/service"
+	"github.com/azcov/bookcabin_test/internal/testutil"
+	"github.com/azcov/bookcabin_test/pkg/cache"
+	"github.com/azcov/bookcabin_test/pkg/clock"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,6 +27,10 @@ type MockFlightService struct {
 	mock.Mock
 }
 
+func (m *MockFlightService) Close(ctx context.Context) error {
+	return nil
+}
+
 func (m *MockFlightService) SerchFlight(ctx context.Context, input *domain.SearchRequest) (*domain.SearchResponse, error) {
 	args := m.Called(ctx, input)
 	if args.Get(0) == nil {
@@ -34,7 +45,7 @@ func TestHandler_SearchFlights(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		// Setup
 		mockSvc := new(MockFlightService)
-		handler := NewHandler(mockSvc)
+		handler := NewHandler(mockSvc, nil, nil, nil)
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 
@@ -42,8 +53,8 @@ func TestHandler_SearchFlights(t *testing.T) {
 			Origin:        "CGK",
 			Destination:   "DPS",
 			DepartureDate: "2025-12-25",
-			Passengers:    1,
-			CabinClass:    "Economy",
+			Passengers:    domain.PassengerCount{Adults: 1},
+			CabinClass:    "economy",
 		}
 		jsonBytes, _ := json.Marshal(reqBody)
 		c.Request, _ = http.NewRequest(http.MethodPost, "/v1/flights/search", bytes.NewBuffer(jsonBytes))
@@ -67,7 +78,7 @@ func TestHandler_SearchFlights(t *testing.T) {
 	t.Run("BadRequest_InvalidJSON", func(t *testing.T) {
 		// Setup
 		mockSvc := new(MockFlightService)
-		handler := NewHandler(mockSvc)
+		handler := NewHandler(mockSvc, nil, nil, nil)
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 
@@ -81,10 +92,126 @@ func TestHandler_SearchFlights(t *testing.T) {
 		mockSvc.AssertNotCalled(t, "SerchFlight")
 	})
 
+	t.Run("BadRequest_InvalidPassengers", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			passengers string
+		}{
+			{name: "NoAdult", passengers: `{"adults": 0, "children": 1}`},
+			{name: "InfantsExceedAdults", passengers: `{"adults": 1, "infants": 2}`},
+			{name: "TooManySeated", passengers: `{"adults": 5, "children": 5}`},
+			{name: "Negative", passengers: `{"adults": 1, "children": -1}`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockSvc := new(MockFlightService)
+				handler := NewHandler(mockSvc, nil, nil, nil)
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+
+				body := `{"origin": "CGK", "destination": "DPS", "departureDate": "2025-12-25", "cabinClass": "Economy", "passengers": ` + tt.passengers + `}`
+				c.Request, _ = http.NewRequest(http.MethodPost, "/v1/flights/search", bytes.NewBufferString(body))
+
+				handler.SearchFlights(c)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				mockSvc.AssertNotCalled(t, "SerchFlight")
+			})
+		}
+	})
+
+	t.Run("BadRequest_InvalidCabinClass", func(t *testing.T) {
+		mockSvc := new(MockFlightService)
+		handler := NewHandler(mockSvc, nil, nil, nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body := `{"origin": "CGK", "destination": "DPS", "departureDate": "2025-12-25", "cabinClass": "Y", "passengers": 1}`
+		c.Request, _ = http.NewRequest(http.MethodPost, "/v1/flights/search", bytes.NewBufferString(body))
+
+		handler.SearchFlights(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockSvc.AssertNotCalled(t, "SerchFlight")
+	})
+
+	t.Run("BadRequest_InvalidFareBrand", func(t *testing.T) {
+		mockSvc := new(MockFlightService)
+		handler := NewHandler(mockSvc, nil, nil, nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body := `{"origin": "CGK", "destination": "DPS", "departureDate": "2025-12-25", "cabinClass": "economy", "passengers": 1, "fareBrand": "flexi"}`
+		c.Request, _ = http.NewRequest(http.MethodPost, "/v1/flights/search", bytes.NewBufferString(body))
+
+		handler.SearchFlights(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_fare_brand")
+		mockSvc.AssertNotCalled(t, "SerchFlight")
+	})
+
+	t.Run("BadRequest_InvalidMode", func(t *testing.T) {
+		for _, body := range []string{
+			`{"origin": "CGK", "destination": "DPS", "departureDate": "2025-12-25", "cabinClass": "economy", "passengers": 1, "mode": "eventually"}`,
+			`{"origin": "CGK", "destination": "DPS", "departureDate": "2025-12-25", "cabinClass": "economy", "passengers": 1, "mode": "fast", "maxWaitMs": -1}`,
+		} {
+			mockSvc := new(MockFlightService)
+			handler := NewHandler(mockSvc, nil, nil, nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/flights/search", bytes.NewBufferString(body))
+
+			handler.SearchFlights(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockSvc.AssertNotCalled(t, "SerchFlight")
+		}
+	})
+
+	t.Run("NormalizesCabinClass", func(t *testing.T) {
+		mockSvc := new(MockFlightService)
+		handler := NewHandler(mockSvc, nil, nil, nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body := `{"origin": "CGK", "destination": "DPS", "departureDate": "2025-12-25", "cabinClass": "Premium Economy", "passengers": 1}`
+		c.Request, _ = http.NewRequest(http.MethodPost, "/v1/flights/search", bytes.NewBufferString(body))
+
+		mockSvc.On("SerchFlight", mock.Anything, mock.MatchedBy(func(req *domain.SearchRequest) bool {
+			return req.CabinClass == consts.CabinClassPremiumEconomy
+		})).Return(&domain.SearchResponse{}, nil)
+
+		handler.SearchFlights(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("LegacyPassengerNumber", func(t *testing.T) {
+		mockSvc := new(MockFlightService)
+		handler := NewHandler(mockSvc, nil, nil, nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body := `{"origin": "CGK", "destination": "DPS", "departureDate": "2025-12-25", "cabinClass": "Economy", "passengers": 2}`
+		c.Request, _ = http.NewRequest(http.MethodPost, "/v1/flights/search", bytes.NewBufferString(body))
+
+		mockSvc.On("SerchFlight", mock.Anything, mock.MatchedBy(func(req *domain.SearchRequest) bool {
+			return req.Passengers == domain.PassengerCount{Adults: 2}
+		})).Return(&domain.SearchResponse{}, nil)
+
+		handler.SearchFlights(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockSvc.AssertExpectations(t)
+	})
+
 	t.Run("ServiceError", func(t *testing.T) {
 		// Setup
 		mockSvc := new(MockFlightService)
-		handler := NewHandler(mockSvc)
+		handler := NewHandler(mockSvc, nil, nil, nil)
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 
@@ -92,8 +219,8 @@ func TestHandler_SearchFlights(t *testing.T) {
 			Origin:        "CGK",
 			Destination:   "DPS",
 			DepartureDate: "2025-12-25",
-			Passengers:    1,
-			CabinClass:    "Economy",
+			Passengers:    domain.PassengerCount{Adults: 1},
+			CabinClass:    "economy",
 		}
 		jsonBytes, _ := json.Marshal(reqBody)
 		c.Request, _ = http.NewRequest(http.MethodPost, "/v1/flights/search", bytes.NewBuffer(jsonBytes))
@@ -110,3 +237,240 @@ func TestHandler_SearchFlights(t *testing.T) {
 		mockSvc.AssertExpectations(t)
 	})
 }
+
+func TestHandler_Reference(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(nil, service.NewReferenceService(), nil, nil)
+
+	t.Run("ListAirlines", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/v1/reference/airlines", nil)
+
+		handler.ListAirlines(c)
+
+		var resp struct {
+			Airlines []catalog.Airline `json:"airlines"`
+		}
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp.Airlines)
+	})
+
+	t.Run("ListAircraft", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/v1/reference/aircraft", nil)
+
+		handler.ListAircraft(c)
+
+		var resp struct {
+			Aircraft []catalog.Aircraft `json:"aircraft"`
+		}
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp.Aircraft)
+	})
+}
+
+func TestHandler_Chaos(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(nil, nil, service.NewChaosService(chaos.NewInjector(chaos.Config{Enabled: true, Seed: 1}, nil)), nil)
+
+	t.Run("UpdateProviderChaos", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := []byte(`{"latency_min_ms":10,"latency_max_ms":20,"error_rate":0.5,"error_type":"rate_limit_exceeded"}`)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/v1/admin/chaos/lion", bytes.NewBuffer(body))
+		c.Params = gin.Params{{Key: "provider", Value: "lion"}}
+
+		handler.UpdateProviderChaos(c)
+
+		var resp chaos.Config
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 0.5, resp.LionAir.ErrorRate)
+		assert.Equal(t, chaos.ErrorTypeRateLimit, resp.LionAir.ErrorType)
+	})
+
+	t.Run("UpdateProviderChaos_UnknownProvider", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/v1/admin/chaos/sriwijaya", bytes.NewBufferString(`{}`))
+		c.Params = gin.Params{{Key: "provider", Value: "sriwijaya"}}
+
+		handler.UpdateProviderChaos(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("UpdateChaos_Invalid", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/v1/admin/chaos", bytes.NewBufferString(`{"enabled":true,"airasia":{"error_rate":1.5}}`))
+
+		handler.UpdateChaos(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("GetChaos", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/v1/admin/chaos", nil)
+
+		handler.GetChaos(c)
+
+		var resp chaos.Config
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.Enabled)
+		assert.Equal(t, 0.5, resp.LionAir.ErrorRate)
+	})
+}
+
+func TestHandler_Cache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewGoCache(cache.CacheConfig{})
+	req := domain.SearchRequest{Origin: "CGK", Destination: "DPS", DepartureDate: "2025-12-25"}
+	assert.NoError(t, c.Set(req.ToCacheKey(), domain.SearchResponse{}))
+	handler := NewHandler(nil, nil, nil, service.NewCacheAdminService(c, nil, clock.New()))
+
+	t.Run("ListCacheKeys", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request, _ = http.NewRequest(http.MethodGet, "/v1/admin/cache/keys?prefix=search_flight:", nil)
+
+		handler.ListCacheKeys(ctx)
+
+		var resp struct {
+			Keys  []string `json:"keys"`
+			Count int      `json:"count"`
+		}
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []string{req.ToCacheKey()}, resp.Keys)
+		assert.Equal(t, 1, resp.Count)
+	})
+
+	t.Run("GetCacheEntry_NotFound", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request, _ = http.NewRequest(http.MethodGet, "/v1/admin/cache/entry?key=missing", nil)
+
+		handler.GetCacheEntry(ctx)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("PurgeCache_Invalid", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request, _ = http.NewRequest(http.MethodDelete, "/v1/admin/cache?origin=CGK", nil)
+
+		handler.PurgeCache(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("PurgeCache", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request, _ = http.NewRequest(http.MethodDelete, "/v1/admin/cache?origin=CGK&destination=DPS", nil)
+
+		handler.PurgeCache(ctx)
+
+		var resp domain.CachePurgeResponse
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Purged)
+	})
+}
+
+func TestRouter_Metrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRouter(NewHandler(nil, nil, nil, nil), RouterConfig{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="/v1/health",status="200"}`)
+	assert.Contains(t, w.Body.String(), `http_request_duration_seconds_bucket{method="GET",route="/v1/health",status="200"`)
+}
+
+func TestRouter_Tracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sr, restore := testutil.NewRecorder()
+	defer restore()
+	r := NewRouter(NewHandler(nil, nil, nil, nil), RouterConfig{})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/health", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	spans := sr.Ended()
+	if assert.Len(t, spans, 1) {
+		// The request continues the inbound trace
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+		assert.True(t, spans[0].Parent().IsRemote())
+	}
+}
+
+func TestRouter_Admin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(nil, nil, service.NewChaosService(chaos.NewInjector(chaos.Config{Seed: 1}, nil)), nil)
+
+	adminRoutes := [][2]string{
+		{http.MethodGet, "/v1/admin/chaos"},
+		{http.MethodGet, "/v1/admin/cache/keys"},
+		{http.MethodGet, "/v1/admin/cache/entry"},
+		{http.MethodGet, "/v1/admin/cache/stats"},
+		{http.MethodDelete, "/v1/admin/cache"},
+	}
+	serve := func(r *gin.Engine, method, path, auth string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("NotRegistered_WithoutToken", func(t *testing.T) {
+		r := NewRouter(h, RouterConfig{})
+		for _, route := range adminRoutes {
+			assert.Equal(t, http.StatusNotFound, serve(r, route[0], route[1], "Bearer "), route[1])
+		}
+	})
+
+	t.Run("TokenRequired", func(t *testing.T) {
+		r := NewRouter(h, RouterConfig{AdminToken: "s3cret"})
+		tests := []struct {
+			name     string
+			auth     string
+			expected int
+		}{
+			{name: "Missing", auth: "", expected: http.StatusUnauthorized},
+			{name: "Wrong", auth: "Bearer guess", expected: http.StatusUnauthorized},
+			{name: "Not bearer", auth: "s3cret", expected: http.StatusUnauthorized},
+			{name: "Valid", auth: "Bearer s3cret", expected: http.StatusOK},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.expected, serve(r, http.MethodGet, "/v1/admin/chaos", tt.auth))
+			})
+		}
+		for _, route := range adminRoutes {
+			assert.Equal(t, http.StatusUnauthorized, serve(r, route[0], route[1], ""), route[1])
+		}
+	})
+}
@@ -2,24 +2,51 @@ package api
 
 import (
 	"github.com/azcov/bookcabin_test/pkg/httpz"
+	"github.com/azcov/bookcabin_test/pkg/metrics"
 	"github.com/gin-gonic/gin"
 )
 
+// RouterConfig configures the routes of NewRouter.
+type RouterConfig struct {
+	// ServiceName names the server in the request spans.
+	ServiceName string
+	// AdminToken guards the admin endpoints; they are not registered
+	// without it.
+	AdminToken string
+}
+
 // NewRouter creates a gin engine and registers routes for the API.
 // Pass a previously created handler to wire the endpoints up.
-func NewRouter(h *Handler) *gin.Engine {
+func NewRouter(h *Handler, cfg RouterConfig) *gin.Engine {
 	r := gin.New()
-	// Add our middlewares: request id, recoverer and logger
+	// Add our middlewares: tracing, request id, recoverer and logger
 	r.Use(gin.Recovery()) // still use gin recovery as a baseline
+	r.Use(httpz.Tracing(cfg.ServiceName))
 	r.Use(httpz.RequestID())
 	r.Use(httpz.Recovery())
 	r.Use(httpz.Logger())
+	r.Use(httpz.Metrics())
+
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	v1 := r.Group("/v1")
 	{
 		v1.POST("/flights/search", h.SearchFlights)
+		v1.GET("/reference/airlines", h.ListAirlines)
+		v1.GET("/reference/aircraft", h.ListAircraft)
 		v1.GET("/health", func(c *gin.Context) { c.JSON(200, gin.H{"status": "ok"}) })
 	}
 
+	if cfg.AdminToken != "" {
+		admin := v1.Group("/admin", httpz.AdminAuth(cfg.AdminToken))
+		admin.GET("/chaos", h.GetChaos)
+		admin.PUT("/chaos", h.UpdateChaos)
+		admin.PUT("/chaos/:provider", h.UpdateProviderChaos)
+		admin.GET("/cache/keys", h.ListCacheKeys)
+		admin.GET("/cache/entry", h.GetCacheEntry)
+		admin.GET("/cache/stats", h.GetCacheStats)
+		admin.DELETE("/cache", h.PurgeCache)
+	}
+
 	return r
 }
@@ -1 +1,9 @@
 package util
+
+import "github.com/leekchan/accounting"
+
+// FormatMoney formats an amount in the given currency, e.g. "IDR 1.250.000".
+func FormatMoney(amount int, currency string) string {
+	ac := accounting.Accounting{Symbol: currency, Precision: 0, Format: "%s %v", Thousand: ".", Decimal: ","}
+	return ac.FormatMoney(amount)
+}
@@ -3,19 +3,33 @@ package util
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 var re = regexp.MustCompile(`^([A-Za-z]+)(\d+)$`)
 
+var weightRe = regexp.MustCompile(`(?i)(\d+)\s*kg`)
+
 func ParseFlightNumber(fn string) (airline, number string, err error) {
 	matches := re.FindStringSubmatch(fn)
-	if len(matches) >= 3 || len(matches) == 0 {
+	if len(matches) < 3 {
 		return "", "", fmt.Errorf("invalid flight number format")
 	}
 	return matches[1], matches[2], nil
 }
 
+// ParseWeightKg returns the first weight in kilograms found in s, e.g. 20 for
+// "20kg checked" or "20 kg", and 0 when there is none.
+func ParseWeightKg(s string) int {
+	matches := weightRe.FindStringSubmatch(s)
+	if len(matches) < 2 {
+		return 0
+	}
+	kg, _ := strconv.Atoi(matches[1])
+	return kg
+}
+
 func FormatDurationMinute(minutes int) string {
 	var result []string
 	d := minutes / (24 * 60)
@@ -37,8 +51,27 @@ func FormatDurationMinute(minutes int) string {
 	return strings.Join(result, " ")
 }
 
+// FormatDurationString parses a duration formatted like FormatDurationMinute
+// ("1d 2h 5m", "3h 5m", "55m") back into minutes. Unknown parts are ignored.
 func FormatDurationString(duration string) (minutes int) {
 	var d, h, m int
+	for _, part := range strings.Fields(duration) {
+		if len(part) < 2 {
+			continue
+		}
+		n, err := strconv.Atoi(part[:len(part)-1])
+		if err != nil {
+			continue
+		}
+		switch part[len(part)-1] {
+		case 'd':
+			d = n
+		case 'h':
+			h = n
+		case 'm':
+			m = n
+		}
+	}
 
 	return d*24*60 + h*60 + m
 }
@@ -1 +1,50 @@
 package util
+
+import (
+	"time"
+	// Embed the timezone database so airport zones resolve in minimal images
+	_ "time/tzdata"
+
+	"github.com/azcov/bookcabin_test/internal/consts"
+)
+
+const DateFormat = "2006-01-02"
+
+// AirportLocation returns the timezone of an airport. ok is false for
+// airports without a known timezone.
+func AirportLocation(airport string) (loc *time.Location, ok bool) {
+	name, ok := consts.AirportCodeToTimezone[airport]
+	if !ok {
+		return nil, false
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+// ToAirportLocal converts t to the local time of the airport. Times at
+// airports without a known timezone keep the offset the provider sent.
+func ToAirportLocal(t time.Time, airport string) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	loc, ok := AirportLocation(airport)
+	if !ok {
+		return t
+	}
+	return t.In(loc)
+}
+
+// AirportLocalDate returns the calendar date of t at the airport, formatted
+// as DateFormat.
+func AirportLocalDate(t time.Time, airport string) string {
+	return ToAirportLocal(t, airport).Format(DateFormat)
+}
+
+// DurationMinutes returns the elapsed minutes between two instants. It is
+// independent of the timezones the times are expressed in.
+func DurationMinutes(from, to time.Time) int {
+	return int(to.Sub(from).Round(time.Minute).Minutes())
+}
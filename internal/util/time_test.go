@@ -0,0 +1,108 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAirportLocalDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		t        time.Time
+		airport  string
+		expected string
+	}{
+		{
+			name:     "UTC evening is next day in Jakarta",
+			t:        time.Date(2025, 12, 14, 18, 30, 0, 0, time.UTC),
+			airport:  "CGK",
+			expected: "2025-12-15",
+		},
+		{
+			name:     "UTC evening is next day in Bali",
+			t:        time.Date(2025, 12, 14, 16, 30, 0, 0, time.UTC),
+			airport:  "DPS",
+			expected: "2025-12-15",
+		},
+		{
+			name:     "Bali time departing Jakarta keeps Jakarta date",
+			t:        time.Date(2025, 12, 15, 0, 30, 0, 0, time.FixedZone("WITA", 8*3600)),
+			airport:  "CGK",
+			expected: "2025-12-14",
+		},
+		{
+			name:     "Unknown airport keeps provider offset",
+			t:        time.Date(2025, 12, 15, 0, 30, 0, 0, time.FixedZone("WITA", 8*3600)),
+			airport:  "XXX",
+			expected: "2025-12-15",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AirportLocalDate(tt.t, tt.airport))
+		})
+	}
+}
+
+func TestToAirportLocal(t *testing.T) {
+	utc := time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)
+
+	local := ToAirportLocal(utc, "DPS")
+	assert.True(t, local.Equal(utc))
+	_, offset := local.Zone()
+	assert.Equal(t, 8*3600, offset)
+
+	assert.True(t, ToAirportLocal(time.Time{}, "DPS").IsZero())
+}
+
+func TestDurationMinutes(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	wita := time.FixedZone("WITA", 8*3600)
+
+	tests := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		expected int
+	}{
+		{
+			name:     "Same timezone",
+			from:     time.Date(2025, 12, 15, 6, 0, 0, 0, wib),
+			to:       time.Date(2025, 12, 15, 7, 40, 0, 0, wib),
+			expected: 100,
+		},
+		{
+			name:     "Crossing eastward",
+			from:     time.Date(2025, 12, 15, 6, 0, 0, 0, wib),
+			to:       time.Date(2025, 12, 15, 8, 50, 0, 0, wita),
+			expected: 110,
+		},
+		{
+			name:     "Crossing westward",
+			from:     time.Date(2025, 12, 15, 9, 0, 0, 0, wita),
+			to:       time.Date(2025, 12, 15, 9, 50, 0, 0, wib),
+			expected: 110,
+		},
+		{
+			name:     "Overnight",
+			from:     time.Date(2025, 12, 15, 23, 30, 0, 0, wib),
+			to:       time.Date(2025, 12, 16, 2, 20, 0, 0, wita),
+			expected: 110,
+		},
+		{
+			name:     "Rounded to the minute",
+			from:     time.Date(2025, 12, 15, 6, 0, 0, 0, wib),
+			to:       time.Date(2025, 12, 15, 7, 39, 40, 0, wib),
+			expected: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DurationMinutes(tt.from, tt.to))
+		})
+	}
+}
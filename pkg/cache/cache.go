@@ -1,10 +1,91 @@
 package cache
 
-import "time"
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/azcov/bookcabin_test/pkg/clock"
+)
 
 type Cache interface {
 	Get(key string) (any, error)
 	Set(key string, value any) error
 	SetWithExpiration(key string, value any, exp time.Duration) error
+	// SetWithStale sets a value that turns stale after stale and expires
+	// after exp, so that it can still be served while it is refreshed.
+	SetWithStale(key string, value any, stale, exp time.Duration) error
+	// GetEntry returns a value with its timestamps.
+	GetEntry(key string) (Entry, error)
 	Delete(key string) error
+
+	// Keys lists the keys starting with prefix, sorted.
+	Keys(prefix string) ([]string, error)
+	// Peek is GetEntry for inspection. It neither changes the cache nor
+	// counts in the stats.
+	Peek(key string) (Entry, error)
+	Stats() (Stats, error)
+}
+
+// Stats counts the lookups of a cache and the entries it evicted.
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// Evictions counts the expired entries this cache removed itself. Redis
+	// expires keys on the server, so the Redis cache reports none and
+	// ServerEvictions instead.
+	Evictions uint64 `json:"evictions"`
+	// ServerEvictions are the keys the Redis server expired or evicted,
+	// including those of its other users.
+	ServerEvictions uint64 `json:"server_evictions,omitempty"`
+}
+
+// counters backs Stats for the cache implementations.
+type counters struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// record counts a lookup that returned err.
+func (c *counters) record(err error) {
+	switch {
+	case err == nil:
+		c.hits.Add(1)
+	case errors.Is(err, ErrCacheNotFound):
+		c.misses.Add(1)
+	}
+}
+
+func (c *counters) stats() Stats {
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Evictions: c.evictions.Load()}
+}
+
+// Entry is a cached value with the times it was stored, turns stale and
+// expires. Zero times mean never.
+type Entry struct {
+	Value     any
+	StoredAt  time.Time
+	StaleAt   time.Time
+	ExpiresAt time.Time
+}
+
+// Stale reports whether the entry is past its stale time at now.
+func (e Entry) Stale(now time.Time) bool {
+	return !e.StaleAt.IsZero() && !now.Before(e.StaleAt)
+}
+
+// New returns the Cache selected by cfg.Driver, or a Cache that stores
+// nothing when cfg.Enabled is false. codec serializes values for the backends
+// that store bytes.
+func New(cfg CacheConfig, clk clock.Clock, codec Codec) Cache {
+	if !cfg.Enabled {
+		return NewNoop()
+	}
+	switch cfg.Driver {
+	case DriverRedis:
+		return NewRedisCache(cfg, clk, codec)
+	default:
+		return NewGoCacheWithClock(cfg, clk)
+	}
 }
@@ -0,0 +1,29 @@
+package cache
+
+import "encoding/json"
+
+// Codec serializes values for caches that store bytes, such as Redis.
+type Codec interface {
+	Marshal(value any) ([]byte, error)
+	Unmarshal(data []byte) (any, error)
+}
+
+type jsonCodec[T any] struct{}
+
+// NewJSONCodec returns a Codec storing values as JSON. Unmarshal returns a T,
+// so values read back have the same type as the ones stored.
+func NewJSONCodec[T any]() Codec {
+	return jsonCodec[T]{}
+}
+
+func (jsonCodec[T]) Marshal(value any) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec[T]) Unmarshal(data []byte) (any, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -1,7 +1,25 @@
 package cache
 
+const (
+	DriverMemory = "memory"
+	DriverRedis  = "redis"
+)
+
 type CacheConfig struct {
 	Enabled               bool  `mapstructure:"enabled" json:"enabled" envconfig:"ENABLED"`
 	ExpirationMinute      int64 `mapstructure:"expiration_minute" json:"expiration_minute" envconfig:"EXPIRATION_MINUTE"`
 	CleanupIntervalMinute int64 `mapstructure:"cleanup_interval_minute" json:"cleanup_interval_minute" envconfig:"CLEANUP_INTERVAL_MINUTE"`
+	// Driver selects the backend: memory (default) or redis.
+	Driver string      `mapstructure:"driver" json:"driver" envconfig:"DRIVER"`
+	Redis  RedisConfig `mapstructure:"redis" json:"redis" envconfig:"REDIS"`
+}
+
+type RedisConfig struct {
+	Addr     string `mapstructure:"addr" json:"addr" envconfig:"ADDR"`
+	Password string `mapstructure:"password" json:"-" envconfig:"PASSWORD"`
+	DB       int    `mapstructure:"db" json:"db" envconfig:"DB"`
+	// KeyPrefix is prepended to every key so that several services can share
+	// a Redis database.
+	KeyPrefix string `mapstructure:"key_prefix" json:"key_prefix" envconfig:"KEY_PREFIX"`
+	TimeoutMs int    `mapstructure:"timeout_ms" json:"timeout_ms" envconfig:"TIMEOUT_MS"`
 }
@@ -1,21 +1,40 @@
 package cache
 
 import (
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/azcov/bookcabin_test/pkg/clock"
 	go_cache "github.com/patrickmn/go-cache"
 )
 
-var DEFAULT_CACHE_EXPIRATION int64 = 5 * 60 * 1000  // 5 minutes in milliseconds
-var DEFAULT_CLEANUP_INTERVAL int64 = 10 * 60 * 1000 // 10 minutes in milliseconds
+var DEFAULT_CACHE_EXPIRATION int64 = 5  // minutes
+var DEFAULT_CLEANUP_INTERVAL int64 = 10 // minutes
 
 type goCache struct {
 	defaultExpiration time.Duration
 	cleanupInterval   time.Duration
 	cache             *go_cache.Cache
+	clock             clock.Clock
+	counters          counters
+}
+
+// goCacheItem carries its own times so that expiry follows the injected
+// clock. go-cache still evicts by wall time in the background.
+type goCacheItem struct {
+	value     any
+	storedAt  time.Time
+	staleAt   time.Time
+	expiresAt time.Time
 }
 
 func NewGoCache(cfg CacheConfig) Cache {
+	return NewGoCacheWithClock(cfg, clock.New())
+}
+
+// NewGoCacheWithClock returns an in-memory Cache whose entries expire on clk.
+func NewGoCacheWithClock(cfg CacheConfig, clk clock.Clock) Cache {
 	if cfg.ExpirationMinute <= 0 {
 		cfg.ExpirationMinute = DEFAULT_CACHE_EXPIRATION
 	}
@@ -25,36 +44,92 @@ func NewGoCache(cfg CacheConfig) Cache {
 	defaultExp := time.Duration(cfg.ExpirationMinute) * time.Minute
 	cleanupInt := time.Duration(cfg.CleanupIntervalMinute) * time.Minute
 
-	c := go_cache.New(defaultExp, cleanupInt)
-	return &goCache{
+	gc := &goCache{
 		defaultExpiration: defaultExp,
 		cleanupInterval:   cleanupInt,
-		cache:             c,
+		cache:             go_cache.New(defaultExp, cleanupInt),
+		clock:             clk,
 	}
+	// Also called on Delete, so only expired items count as evicted
+	gc.cache.OnEvicted(func(_ string, v any) {
+		if gc.expired(v.(goCacheItem)) {
+			gc.counters.evictions.Add(1)
+		}
+	})
+	return gc
 }
 
 // Implement Cache interface methods here
 func (gc *goCache) Get(key string) (any, error) {
-	// Implementation goes here
+	entry, err := gc.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Value, nil
+}
+
+// GetEntry removes the item under key when it expired on the clock, which
+// counts as an eviction.
+func (gc *goCache) GetEntry(key string) (Entry, error) {
+	entry, expired, err := gc.lookup(key)
+	if expired {
+		gc.cache.Delete(key)
+	}
+	gc.counters.record(err)
+	return entry, err
+}
+
+// Peek reports expired items as not found but leaves their removal to
+// lookups and the cleanup.
+func (gc *goCache) Peek(key string) (Entry, error) {
+	entry, _, err := gc.lookup(key)
+	return entry, err
+}
+
+// lookup returns the entry under key, reporting whether it was found expired.
+func (gc *goCache) lookup(key string) (entry Entry, expired bool, err error) {
 	v, found := gc.cache.Get(key)
 	if !found {
-		return nil, ErrCacheNotFound
+		return Entry{}, false, ErrCacheNotFound
+	}
+	item := v.(goCacheItem)
+	if gc.expired(item) {
+		return Entry{}, true, ErrCacheNotFound
 	}
-	return v, nil
+	return Entry{Value: item.value, StoredAt: item.storedAt, StaleAt: item.staleAt, ExpiresAt: item.expiresAt}, false, nil
+}
+
+func (gc *goCache) expired(item goCacheItem) bool {
+	return !item.expiresAt.IsZero() && !gc.clock.Now().Before(item.expiresAt)
 }
 
 func (gc *goCache) Set(key string, value any) error {
 	// Implementation goes here
-	gc.cache.Set(key, value, gc.defaultExpiration)
-	return nil
+	return gc.SetWithExpiration(key, value, 0)
 }
 
 // SetWithExpiration sets a value in the cache with a specific expiration time
 // 0 means default expiration
 // -1 means no expiration
 func (gc *goCache) SetWithExpiration(key string, value any, exp time.Duration) error {
-	// Implementation goes here
-	gc.cache.Set(key, value, exp)
+	return gc.SetWithStale(key, value, 0, exp)
+}
+
+// SetWithStale sets a value that turns stale after stale, 0 meaning never,
+// and expires like SetWithExpiration.
+func (gc *goCache) SetWithStale(key string, value any, stale, exp time.Duration) error {
+	if exp == 0 {
+		exp = gc.defaultExpiration
+	}
+	now := gc.clock.Now()
+	item := goCacheItem{value: value, storedAt: now}
+	if stale > 0 {
+		item.staleAt = now.Add(stale)
+	}
+	if exp > 0 {
+		item.expiresAt = now.Add(exp)
+	}
+	gc.cache.Set(key, item, exp)
 	return nil
 }
 
@@ -63,3 +138,18 @@ func (gc *goCache) Delete(key string) error {
 	gc.cache.Delete(key)
 	return nil
 }
+
+func (gc *goCache) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for key, v := range gc.cache.Items() {
+		if strings.HasPrefix(key, prefix) && !gc.expired(v.Object.(goCacheItem)) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (gc *goCache) Stats() (Stats, error) {
+	return gc.counters.stats(), nil
+}
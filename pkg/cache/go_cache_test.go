@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoCache_GetEntry(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	c := NewGoCacheWithClock(CacheConfig{}, clk)
+
+	assert.NoError(t, c.SetWithStale("search", "r1", time.Minute, 2*time.Minute))
+
+	entry, err := c.GetEntry("search")
+	assert.NoError(t, err)
+	assert.Equal(t, "r1", entry.Value)
+	assert.True(t, entry.StoredAt.Equal(clk.Now()))
+	assert.False(t, entry.Stale(clk.Now()))
+
+	clk.Advance(time.Minute)
+	entry, err = c.GetEntry("search")
+	assert.NoError(t, err)
+	assert.True(t, entry.Stale(clk.Now()))
+
+	clk.Advance(time.Minute)
+	_, err = c.GetEntry("search")
+	assert.ErrorIs(t, err, ErrCacheNotFound)
+
+	// Entries set without a soft TTL never turn stale
+	assert.NoError(t, c.Set("fresh", "r2"))
+	entry, err = c.GetEntry("fresh")
+	assert.NoError(t, err)
+	assert.True(t, entry.StaleAt.IsZero())
+	assert.Equal(t, clk.Now().Add(5*time.Minute), entry.ExpiresAt)
+}
+
+func TestGoCache_KeysAndStats(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+	c := NewGoCacheWithClock(CacheConfig{}, clk)
+
+	assert.NoError(t, c.Set("search:b", "r1"))
+	assert.NoError(t, c.Set("search:a", "r2"))
+	assert.NoError(t, c.SetWithExpiration("raw:a", "r3", time.Minute))
+
+	keys, err := c.Keys("search:")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"search:a", "search:b"}, keys)
+
+	_, err = c.Get("search:a")
+	assert.NoError(t, err)
+	_, err = c.Get("missing")
+	assert.ErrorIs(t, err, ErrCacheNotFound)
+	// Peeking is not a lookup
+	_, err = c.Peek("search:b")
+	assert.NoError(t, err)
+	// Deleting is not an eviction
+	assert.NoError(t, c.Delete("search:b"))
+
+	clk.Advance(time.Minute)
+	keys, err = c.Keys("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"search:a"}, keys)
+	// Peeking at an expired entry neither removes it nor counts
+	_, err = c.Peek("raw:a")
+	assert.ErrorIs(t, err, ErrCacheNotFound)
+	stats, err := c.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, Stats{Hits: 1, Misses: 1}, stats)
+
+	_, err = c.Get("raw:a")
+	assert.ErrorIs(t, err, ErrCacheNotFound)
+
+	stats, err = c.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, Stats{Hits: 1, Misses: 2, Evictions: 1}, stats)
+}
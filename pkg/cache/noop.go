@@ -0,0 +1,46 @@
+package cache
+
+import "time"
+
+type noopCache struct{}
+
+// NewNoop returns a Cache that stores nothing, used when caching is disabled.
+func NewNoop() Cache {
+	return noopCache{}
+}
+
+func (noopCache) Get(key string) (any, error) {
+	return nil, ErrCacheNotFound
+}
+
+func (noopCache) Set(key string, value any) error {
+	return nil
+}
+
+func (noopCache) SetWithExpiration(key string, value any, exp time.Duration) error {
+	return nil
+}
+
+func (noopCache) SetWithStale(key string, value any, stale, exp time.Duration) error {
+	return nil
+}
+
+func (noopCache) GetEntry(key string) (Entry, error) {
+	return Entry{}, ErrCacheNotFound
+}
+
+func (noopCache) Delete(key string) error {
+	return nil
+}
+
+func (noopCache) Keys(prefix string) ([]string, error) {
+	return nil, nil
+}
+
+func (noopCache) Peek(key string) (Entry, error) {
+	return Entry{}, ErrCacheNotFound
+}
+
+func (noopCache) Stats() (Stats, error) {
+	return Stats{}, nil
+}
@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/redis/go-redis/v9"
+)
+
+var DEFAULT_REDIS_TIMEOUT = 500 * time.Millisecond
+
+// Fields of the hash an entry is stored in. Times are Unix nanoseconds, 0
+// meaning never.
+const (
+	redisFieldValue     = "v"
+	redisFieldStoredAt  = "t"
+	redisFieldStaleAt   = "s"
+	redisFieldExpiresAt = "e"
+)
+
+type redisCache struct {
+	client            redis.UniversalClient
+	codec             Codec
+	clock             clock.Clock
+	prefix            string
+	timeout           time.Duration
+	defaultExpiration time.Duration
+	counters          counters
+}
+
+// NewRedisCache returns a Cache shared by every replica pointing at the same
+// Redis. Values are serialized with codec and keys prefixed with
+// cfg.Redis.KeyPrefix. clk stamps the entries; Redis itself expires them.
+func NewRedisCache(cfg CacheConfig, clk clock.Clock, codec Codec) Cache {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	return NewRedisCacheWithClient(cfg, clk, client, codec)
+}
+
+// NewRedisCacheWithClient is NewRedisCache on an existing client.
+func NewRedisCacheWithClient(cfg CacheConfig, clk clock.Clock, client redis.UniversalClient, codec Codec) Cache {
+	if cfg.ExpirationMinute <= 0 {
+		cfg.ExpirationMinute = DEFAULT_CACHE_EXPIRATION
+	}
+	timeout := time.Duration(cfg.Redis.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = DEFAULT_REDIS_TIMEOUT
+	}
+	if clk == nil {
+		clk = clock.New()
+	}
+	return &redisCache{
+		client:            client,
+		codec:             codec,
+		clock:             clk,
+		prefix:            cfg.Redis.KeyPrefix,
+		timeout:           timeout,
+		defaultExpiration: time.Duration(cfg.ExpirationMinute) * time.Minute,
+	}
+}
+
+func (rc *redisCache) Get(key string) (any, error) {
+	entry, err := rc.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Value, nil
+}
+
+func (rc *redisCache) GetEntry(key string) (Entry, error) {
+	entry, err := rc.Peek(key)
+	rc.counters.record(err)
+	return entry, err
+}
+
+func (rc *redisCache) Peek(key string) (Entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rc.timeout)
+	defer cancel()
+	fields, err := rc.client.HGetAll(ctx, rc.prefix+key).Result()
+	if err != nil {
+		return Entry{}, err
+	}
+	data, ok := fields[redisFieldValue]
+	if !ok {
+		return Entry{}, ErrCacheNotFound
+	}
+	value, err := rc.codec.Unmarshal([]byte(data))
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Value:     value,
+		StoredAt:  parseRedisTime(fields[redisFieldStoredAt]),
+		StaleAt:   parseRedisTime(fields[redisFieldStaleAt]),
+		ExpiresAt: parseRedisTime(fields[redisFieldExpiresAt]),
+	}, nil
+}
+
+func (rc *redisCache) Set(key string, value any) error {
+	return rc.SetWithExpiration(key, value, 0)
+}
+
+// SetWithExpiration sets a value in the cache with a specific expiration time
+// 0 means default expiration
+// -1 means no expiration
+func (rc *redisCache) SetWithExpiration(key string, value any, exp time.Duration) error {
+	return rc.SetWithStale(key, value, 0, exp)
+}
+
+// SetWithStale sets a value that turns stale after stale, 0 meaning never,
+// and expires like SetWithExpiration.
+func (rc *redisCache) SetWithStale(key string, value any, stale, exp time.Duration) error {
+	if exp == 0 {
+		exp = rc.defaultExpiration
+	}
+	data, err := rc.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	now := rc.clock.Now()
+	var staleAt, expiresAt time.Time
+	if stale > 0 {
+		staleAt = now.Add(stale)
+	}
+	if exp > 0 {
+		expiresAt = now.Add(exp)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rc.timeout)
+	defer cancel()
+	k := rc.prefix + key
+	_, err = rc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, k)
+		pipe.HSet(ctx, k,
+			redisFieldValue, data,
+			redisFieldStoredAt, formatRedisTime(now),
+			redisFieldStaleAt, formatRedisTime(staleAt),
+			redisFieldExpiresAt, formatRedisTime(expiresAt),
+		)
+		// Redis keeps keys set without expiration forever
+		if exp > 0 {
+			pipe.PExpire(ctx, k, exp)
+		}
+		return nil
+	})
+	return err
+}
+
+func (rc *redisCache) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rc.timeout)
+	defer cancel()
+	return rc.client.Del(ctx, rc.prefix+key).Err()
+}
+
+// Keys scans the keys under the key prefix, so it does not block Redis on
+// large databases.
+func (rc *redisCache) Keys(prefix string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rc.timeout)
+	defer cancel()
+	var keys []string
+	iter := rc.client.Scan(ctx, 0, escapeRedisPattern(rc.prefix+prefix)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), rc.prefix))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Stats counts the hits and misses of this replica. Redis expires and evicts
+// keys itself and only reports them for the whole server, so they are
+// reported as ServerEvictions rather than Evictions.
+func (rc *redisCache) Stats() (Stats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rc.timeout)
+	defer cancel()
+	info, err := rc.client.Info(ctx, "stats").Result()
+	if err != nil {
+		return Stats{}, err
+	}
+	stats := rc.counters.stats()
+	stats.ServerEvictions = parseServerEvictions(info)
+	return stats, nil
+}
+
+// parseServerEvictions sums the expired and evicted keys of INFO stats.
+func parseServerEvictions(info string) uint64 {
+	var evictions uint64
+	for line := range strings.Lines(info) {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || (name != "expired_keys" && name != "evicted_keys") {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err == nil {
+			evictions += n
+		}
+	}
+	return evictions
+}
+
+// escapeRedisPattern escapes the glob characters of s for SCAN MATCH.
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func formatRedisTime(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func parseRedisTime(s string) time.Time {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/azcov/bookcabin_test/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+type cachedResult struct {
+	ID      string   `json:"id"`
+	Flights []string `json:"flights"`
+}
+
+func newTestRedisCache(t *testing.T) (Cache, *miniredis.Miniredis) {
+	srv := miniredis.RunT(t)
+	cfg := CacheConfig{
+		ExpirationMinute: 5,
+		Redis:            RedisConfig{Addr: srv.Addr(), KeyPrefix: "test:"},
+	}
+	return NewRedisCache(cfg, clock.New(), NewJSONCodec[cachedResult]()), srv
+}
+
+func TestRedisCache(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		c, srv := newTestRedisCache(t)
+		want := cachedResult{ID: "r1", Flights: []string{"f1", "f2"}}
+
+		assert.NoError(t, c.Set("search", want))
+		got, err := c.Get("search")
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.True(t, srv.Exists("test:search"))
+		assert.Equal(t, 5*time.Minute, srv.TTL("test:search"))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		c, _ := newTestRedisCache(t)
+
+		_, err := c.Get("missing")
+
+		assert.ErrorIs(t, err, ErrCacheNotFound)
+	})
+
+	t.Run("Expiration", func(t *testing.T) {
+		c, srv := newTestRedisCache(t)
+
+		assert.NoError(t, c.SetWithExpiration("search", cachedResult{ID: "r1"}, time.Second))
+		srv.FastForward(time.Second)
+		_, err := c.Get("search")
+		assert.ErrorIs(t, err, ErrCacheNotFound)
+
+		assert.NoError(t, c.SetWithExpiration("forever", cachedResult{ID: "r2"}, -1))
+		assert.Zero(t, srv.TTL("test:forever"))
+	})
+
+	t.Run("StaleEntry", func(t *testing.T) {
+		srv := miniredis.RunT(t)
+		clk := clock.NewFake(time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC))
+		c := NewRedisCache(CacheConfig{Redis: RedisConfig{Addr: srv.Addr()}}, clk, NewJSONCodec[cachedResult]())
+
+		assert.NoError(t, c.SetWithStale("search", cachedResult{ID: "r1"}, time.Minute, 2*time.Minute))
+		assert.Equal(t, 2*time.Minute, srv.TTL("search"))
+
+		entry, err := c.GetEntry("search")
+		assert.NoError(t, err)
+		assert.Equal(t, cachedResult{ID: "r1"}, entry.Value)
+		assert.True(t, entry.StoredAt.Equal(clk.Now()))
+		assert.True(t, entry.ExpiresAt.Equal(clk.Now().Add(2*time.Minute)))
+		assert.False(t, entry.Stale(clk.Now()))
+
+		clk.Advance(time.Minute)
+		entry, err = c.GetEntry("search")
+		assert.NoError(t, err)
+		assert.True(t, entry.Stale(clk.Now()))
+
+		// Entries set without a soft TTL never turn stale
+		assert.NoError(t, c.Set("fresh", cachedResult{ID: "r2"}))
+		entry, err = c.GetEntry("fresh")
+		assert.NoError(t, err)
+		assert.True(t, entry.StaleAt.IsZero())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		c, _ := newTestRedisCache(t)
+
+		assert.NoError(t, c.Set("search", cachedResult{ID: "r1"}))
+		assert.NoError(t, c.Delete("search"))
+		_, err := c.Get("search")
+
+		assert.ErrorIs(t, err, ErrCacheNotFound)
+	})
+
+	t.Run("KeysAndStats", func(t *testing.T) {
+		c, srv := newTestRedisCache(t)
+		srv.Set("other", "not ours")
+
+		assert.NoError(t, c.Set("search:b", cachedResult{ID: "r1"}))
+		assert.NoError(t, c.Set("search:a", cachedResult{ID: "r2"}))
+		assert.NoError(t, c.Set("raw*:a", cachedResult{ID: "r3"}))
+
+		keys, err := c.Keys("search:")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"search:a", "search:b"}, keys)
+		// Glob characters in the prefix match literally
+		keys, err = c.Keys("raw*")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"raw*:a"}, keys)
+
+		_, err = c.Get("search:a")
+		assert.NoError(t, err)
+		_, err = c.Get("missing")
+		assert.ErrorIs(t, err, ErrCacheNotFound)
+		_, err = c.Peek("search:b")
+		assert.NoError(t, err)
+
+		stats, err := c.Stats()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), stats.Hits)
+		assert.Equal(t, uint64(1), stats.Misses)
+		// Expiries are the server's, not this cache's
+		assert.Zero(t, stats.Evictions)
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		c, srv := newTestRedisCache(t)
+		srv.SetError("LOADING")
+
+		_, err := c.Get("search")
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCacheNotFound)
+	})
+}
+
+func TestParseServerEvictions(t *testing.T) {
+	info := "# Stats\r\ntotal_connections_received:3\r\nexpired_keys:12\r\nevicted_keys:5\r\nexpired_stale_perc:0.00\r\n"
+
+	assert.Equal(t, uint64(17), parseServerEvictions(info))
+	assert.Zero(t, parseServerEvictions("# Stats\r\n"))
+}
+
+func TestNew_SelectsDriver(t *testing.T) {
+	srv := miniredis.RunT(t)
+
+	_, ok := New(CacheConfig{}, nil, nil).(noopCache)
+	assert.True(t, ok)
+	_, ok = New(CacheConfig{Enabled: true}, nil, nil).(*goCache)
+	assert.True(t, ok)
+	_, ok = New(CacheConfig{Enabled: true, Driver: DriverRedis, Redis: RedisConfig{Addr: srv.Addr()}}, nil, NewJSONCodec[cachedResult]()).(*redisCache)
+	assert.True(t, ok)
+}
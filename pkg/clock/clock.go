@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock abstracts reading time and waiting so that time-dependent code can be
+// driven by a Fake in tests.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// After waits for d and then sends the current time on the channel.
+	After(d time.Duration) <-chan time.Time
+	// WithTimeout returns a copy of ctx that is cancelled with
+	// context.DeadlineExceeded once d has passed on this clock.
+	WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc)
+}
+
+type realClock struct{}
+
+// New returns a Clock backed by the time package.
+func New() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, d)
+}
+
+// Sleep waits for d on c, returning ctx.Err() if ctx is done first.
+func Sleep(ctx context.Context, c Clock, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-c.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
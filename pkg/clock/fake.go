@@ -0,0 +1,163 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when told to. Waiters registered with
+// After and WithTimeout fire once Advance or Set reaches their deadline.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	until time.Time
+	ch    chan time.Time
+}
+
+// NewFake returns a Fake set to now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, &fakeWaiter{until: f.now.Add(d), ch: ch})
+	f.cond.Broadcast()
+	return ch
+}
+
+func (f *Fake) WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	deadline := f.Now().Add(d)
+	tctx := &fakeTimeoutCtx{Context: ctx, deadline: deadline, done: make(chan struct{})}
+	timer := f.After(d)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-timer:
+			tctx.cancel(context.DeadlineExceeded)
+			return
+		case <-ctx.Done():
+			tctx.cancel(ctx.Err())
+		case <-stop:
+		}
+		f.remove(timer)
+	}()
+	var once sync.Once
+	return tctx, func() {
+		once.Do(func() { close(stop) })
+		tctx.cancel(context.Canceled)
+	}
+}
+
+// Advance moves the clock forward by d and fires the waiters that are due.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.setLocked(f.now.Add(d))
+	f.mu.Unlock()
+}
+
+// Set moves the clock to t and fires the waiters that are due.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	f.setLocked(t)
+	f.mu.Unlock()
+}
+
+func (f *Fake) setLocked(t time.Time) {
+	f.now = t
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.until.After(t) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- t
+	}
+	f.waiters = pending
+}
+
+func (f *Fake) remove(ch <-chan time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, w := range f.waiters {
+		if w.ch == ch {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// Waiters returns the number of pending After and WithTimeout waiters.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until at least n waiters are pending, so that a test can
+// Advance after the code under test started waiting.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// fakeTimeoutCtx is a context whose deadline follows a Fake.
+type fakeTimeoutCtx struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func (c *fakeTimeoutCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *fakeTimeoutCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *fakeTimeoutCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *fakeTimeoutCtx) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
@@ -2,4 +2,7 @@ package httpz
 
 type HttpConfig struct {
 	Port int `mapstructure:"port" json:"port" envconfig:"PORT"`
+	// AdminToken is the bearer token of the admin endpoints, which are not
+	// served without one.
+	AdminToken string `mapstructure:"admin_token" json:"-" envconfig:"ADMIN_TOKEN"`
 }
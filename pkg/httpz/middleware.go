@@ -1,8 +1,9 @@
 package httpz
 
 import (
-	"context"
+	"crypto/subtle"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/azcov/bookcabin_test/pkg/consts"
@@ -24,8 +25,8 @@ func RequestID() gin.HandlerFunc {
 		}
 		c.Set(consts.HeaderRequestID, rid)
 
-		// Update the request context so it is available via c.Request.Context()
-		ctx := context.WithValue(c.Request.Context(), consts.HeaderRequestID, rid)
+		// Log lines of the request carry its ID
+		ctx := logger.WithContext(c.Request.Context(), "request_id", rid)
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
@@ -39,11 +40,21 @@ func Logger() gin.HandlerFunc {
 		c.Next()
 		latency := time.Since(start)
 		status := c.Writer.Status()
-		rid := ""
-		if v, ok := c.Get(consts.HeaderRequestID); ok {
-			rid = v.(string)
+		logger.InfoContext(c.Request.Context(), "http_request", "method", c.Request.Method, "path", c.Request.URL.Path, "status", status, "latency_ms", latency.Milliseconds())
+	}
+}
+
+// AdminAuth middleware rejects requests without the bearer token.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			we := &errorz.WrappedError{StatusCode: http.StatusUnauthorized, ErrCode: "unauthorized", Msg: "a valid admin token is required"}
+			c.Abort()
+			JSONResponse(c, nil, we)
+			return
 		}
-		logger.InfoContext(c.Request.Context(), "http_request", "method", c.Request.Method, "path", c.Request.URL.Path, "status", status, "latency_ms", latency.Milliseconds(), "rid", rid)
+		c.Next()
 	}
 }
 
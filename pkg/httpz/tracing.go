@@ -0,0 +1,16 @@
+package httpz
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Tracing middleware starts a span for every request, continuing the trace of
+// an inbound W3C traceparent header. Scrapes of /metrics are not traced.
+func Tracing(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service, otelgin.WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != "/metrics"
+	}))
+}
@@ -1,6 +1,9 @@
 package logger
 
-import "context"
+import (
+	"context"
+	"slices"
+)
 
 type Logger interface {
 	Debug(msg string, keysAndValues ...any)
@@ -14,6 +17,9 @@ type Logger interface {
 	ErrorContext(ctx context.Context, msg string, keysAndValues ...any)
 	WarnContext(ctx context.Context, msg string, keysAndValues ...any)
 	FatalContext(ctx context.Context, msg string, keysAndValues ...any)
+
+	// With returns a logger adding keysAndValues to every line.
+	With(keysAndValues ...any) Logger
 }
 
 var (
@@ -28,6 +34,26 @@ func init() {
 	})
 }
 
+type fieldsKey struct{}
+
+// WithContext returns a copy of ctx whose log lines carry keysAndValues, on
+// top of the fields already scoped to ctx, e.g. the request ID. The Context
+// logging functions also add the trace ID of the span in ctx.
+func WithContext(ctx context.Context, keysAndValues ...any) context.Context {
+	fields := slices.Clip(fieldsFromContext(ctx))
+	return context.WithValue(ctx, fieldsKey{}, append(fields, keysAndValues...))
+}
+
+func fieldsFromContext(ctx context.Context) []any {
+	fields, _ := ctx.Value(fieldsKey{}).([]any)
+	return fields
+}
+
+// With returns the default logger adding keysAndValues to every line.
+func With(keysAndValues ...any) Logger {
+	return defaultLogger.With(keysAndValues...)
+}
+
 // SetLogger sets the global default logger
 func SetLogger(l Logger) {
 	defaultLogger = l
@@ -5,13 +5,26 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/azcov/bookcabin_test/pkg/consts"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Frames between the caller and zap: the zapLogger method, plus the package
+// function for the default logger.
+const (
+	methodCallerSkip  = 1
+	packageCallerSkip = 2
+)
+
 type zapLogger struct {
 	z *zap.Logger
+	// skip is the caller skip z was built with
+	skip int
+}
+
+func newZapLogger(z *zap.Logger, skip int) *zapLogger {
+	return &zapLogger{z: z.WithOptions(zap.AddCallerSkip(skip)), skip: skip}
 }
 
 // NewZapLogger returns a configured Zap logger instance
@@ -38,12 +51,13 @@ func NewZapLogger(c LoggerConfig) Logger {
 	}
 	cfg.Level = zap.NewAtomicLevelAt(lvl)
 
-	l, err := cfg.Build(zap.AddCallerSkip(2))
+	l, err := cfg.Build()
 	if err != nil {
 		panic(err)
 	}
 
-	logger := &zapLogger{z: l}
+	// The returned logger backs the package functions
+	logger := newZapLogger(l, packageCallerSkip)
 
 	// Only set default if not set (thread-safety issue here in init but unlikely in this use case)
 	if defaultLogger == nil {
@@ -73,45 +87,44 @@ func (zl *zapLogger) Fatal(msg string, keysAndValues ...any) {
 }
 
 func (zl *zapLogger) InfoContext(ctx context.Context, msg string, keysAndValues ...any) {
-	zl.z.Info(zl.addRequestID(ctx, msg), toZapFields(keysAndValues...)...)
+	zl.z.Info(msg, contextFields(ctx, keysAndValues)...)
 }
 
 func (zl *zapLogger) ErrorContext(ctx context.Context, msg string, keysAndValues ...any) {
-	zl.z.Error(zl.addRequestID(ctx, msg), toZapFields(keysAndValues...)...)
+	zl.z.Error(msg, contextFields(ctx, keysAndValues)...)
 }
 
 func (zl *zapLogger) WarnContext(ctx context.Context, msg string, keysAndValues ...any) {
-	zl.z.Warn(zl.addRequestID(ctx, msg), toZapFields(keysAndValues...)...)
+	zl.z.Warn(msg, contextFields(ctx, keysAndValues)...)
 }
 
 func (zl *zapLogger) DebugContext(ctx context.Context, msg string, keysAndValues ...any) {
-	zl.z.Debug(zl.addRequestID(ctx, msg), toZapFields(keysAndValues...)...)
+	zl.z.Debug(msg, contextFields(ctx, keysAndValues)...)
 }
 
 func (zl *zapLogger) FatalContext(ctx context.Context, msg string, keysAndValues ...any) {
-	zl.z.Fatal(zl.addRequestID(ctx, msg), toZapFields(keysAndValues...)...)
+	zl.z.Fatal(msg, contextFields(ctx, keysAndValues)...)
 }
 
-func (zl *zapLogger) addRequestID(ctx context.Context, msg string) string {
+func (zl *zapLogger) With(keysAndValues ...any) Logger {
+	// The derived logger is called directly, not through the package
+	// functions
+	z := zl.z.With(toZapFields(keysAndValues...)...)
+	return &zapLogger{z: z.WithOptions(zap.AddCallerSkip(methodCallerSkip - zl.skip)), skip: methodCallerSkip}
+}
+
+// contextFields returns the trace and the fields scoped to ctx followed by
+// keysAndValues.
+func contextFields(ctx context.Context, keysAndValues []any) []zap.Field {
 	if ctx == nil {
-		zl.z.Error("ctx is nil")
-		return msg
-	}
-	val := ctx.Value(consts.HeaderRequestID)
-	if val == nil {
-		zl.z.Error("X-Request-ID is nil")
-		val := ctx.Value("request_id")
-		if val == nil {
-			zl.z.Error("request_id is nil")
-			return msg
-		}
+		return toZapFields(keysAndValues...)
 	}
-	str, ok := val.(string)
-	if !ok || str == "" {
-		zl.z.Error("X-Request-ID is not a string or is empty")
-		return msg
+	var fields []zap.Field
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, zap.String("trace_id", sc.TraceID().String()), zap.String("span_id", sc.SpanID().String()))
 	}
-	return fmt.Sprintf("[R:%v] %s", str, msg)
+	fields = append(fields, toZapFields(fieldsFromContext(ctx)...)...)
+	return append(fields, toZapFields(keysAndValues...)...)
 }
 
 func toZapFields(kvs ...any) []zap.Field {
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/azcov/bookcabin_test/internal/testutil"
+	"github.com/azcov/bookcabin_test/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func observedLogger() (*zapLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return &zapLogger{z: zap.New(core)}, logs
+}
+
+func TestZapLogger_Context(t *testing.T) {
+	t.Run("ScopedFields", func(t *testing.T) {
+		zl, logs := observedLogger()
+		ctx := WithContext(context.Background(), "request_id", "r1")
+		ctx = WithContext(ctx, "provider", "lion")
+
+		zl.InfoContext(ctx, "Provider succeeded", "flights", 2)
+
+		entries := logs.AllUntimed()
+		if assert.Len(t, entries, 1) {
+			// The message is left alone
+			assert.Equal(t, "Provider succeeded", entries[0].Message)
+			assert.Equal(t, map[string]any{"request_id": "r1", "provider": "lion", "flights": int64(2)}, entries[0].ContextMap())
+		}
+	})
+
+	t.Run("Scopes_DoNotLeakIntoParent", func(t *testing.T) {
+		zl, logs := observedLogger()
+		parent := WithContext(context.Background(), "request_id", "r1")
+		_ = WithContext(parent, "provider", "lion")
+
+		zl.InfoContext(parent, "Total results")
+
+		assert.Equal(t, map[string]any{"request_id": "r1"}, logs.AllUntimed()[0].ContextMap())
+	})
+
+	t.Run("Trace", func(t *testing.T) {
+		zl, logs := observedLogger()
+		_, restore := testutil.NewRecorder()
+		defer restore()
+		ctx, span := tracing.Start(context.Background(), "test")
+		defer span.End()
+
+		zl.ErrorContext(ctx, "Error searching flights")
+
+		fields := logs.AllUntimed()[0].ContextMap()
+		assert.Equal(t, span.SpanContext().TraceID().String(), fields["trace_id"])
+		assert.Equal(t, span.SpanContext().SpanID().String(), fields["span_id"])
+	})
+
+	t.Run("NoScope_NoExtraFields", func(t *testing.T) {
+		zl, logs := observedLogger()
+
+		zl.WarnContext(context.Background(), "No scope")
+
+		entries := logs.AllUntimed()
+		if assert.Len(t, entries, 1) {
+			assert.Empty(t, entries[0].ContextMap())
+		}
+	})
+}
+
+func TestZapLogger_With(t *testing.T) {
+	zl, logs := observedLogger()
+
+	l := zl.With("component", "warmup")
+	l.With("route", "CGK-DPS").Info("Warm-up search failed")
+	l.Info("Cache warm-up done")
+
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, map[string]any{"component": "warmup", "route": "CGK-DPS"}, entries[0].ContextMap())
+		assert.Equal(t, map[string]any{"component": "warmup"}, entries[1].ContextMap())
+	}
+}
+
+func TestZapLogger_Caller(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	prev := defaultLogger
+	defer SetLogger(prev)
+	SetLogger(newZapLogger(zap.New(core, zap.AddCaller()), packageCallerSkip))
+
+	assertCaller := func(t *testing.T, line int) {
+		entries := logs.TakeAll()
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "zap_test.go", filepath.Base(entries[0].Caller.File))
+			assert.Equal(t, line, entries[0].Caller.Line)
+		}
+	}
+
+	t.Run("PackageFunctions", func(t *testing.T) {
+		_, _, line, _ := runtime.Caller(0)
+		Info("Cache warm-up done")
+		assertCaller(t, line+1)
+	})
+
+	t.Run("With", func(t *testing.T) {
+		l := With("component", "warmup")
+		_, _, line, _ := runtime.Caller(0)
+		l.Info("Cache warm-up done")
+		assertCaller(t, line+1)
+
+		_, _, line, _ = runtime.Caller(0)
+		l.With("route", "CGK-DPS").WarnContext(context.Background(), "Warm-up search failed")
+		assertCaller(t, line+1)
+	})
+}
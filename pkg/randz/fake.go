@@ -0,0 +1,39 @@
+package randz
+
+import "sync"
+
+// Fake is a Rand returning pinned values. Float64 cycles through Floats, or
+// returns 0 when there are none. NormFloat64 and ExpFloat64 return Norm and
+// Exp.
+type Fake struct {
+	Floats []float64
+	Norm   float64
+	Exp    float64
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewFake returns a Fake cycling through floats.
+func NewFake(floats ...float64) *Fake {
+	return &Fake{Floats: floats}
+}
+
+func (f *Fake) Float64() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.Floats) == 0 {
+		return 0
+	}
+	v := f.Floats[f.next%len(f.Floats)]
+	f.next++
+	return v
+}
+
+func (f *Fake) NormFloat64() float64 {
+	return f.Norm
+}
+
+func (f *Fake) ExpFloat64() float64 {
+	return f.Exp
+}
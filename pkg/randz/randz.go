@@ -0,0 +1,50 @@
+package randz
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Rand abstracts the random draws used for simulation so that outcomes can
+// be pinned in tests.
+type Rand interface {
+	// Float64 returns a number in [0.0, 1.0).
+	Float64() float64
+	// NormFloat64 returns a standard normally distributed number.
+	NormFloat64() float64
+	// ExpFloat64 returns an exponentially distributed number with mean 1.
+	ExpFloat64() float64
+}
+
+type lockedRand struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// New returns a Rand that is safe for concurrent use. Seed 0 seeds from the
+// current time; any other seed gives a reproducible sequence.
+func New(seed int64) Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &lockedRand{rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (r *lockedRand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Float64()
+}
+
+func (r *lockedRand) NormFloat64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.NormFloat64()
+}
+
+func (r *lockedRand) ExpFloat64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.ExpFloat64()
+}
@@ -2,6 +2,7 @@ package ratelimit
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -10,6 +11,9 @@ import (
 // Limiter abstracts the rate limiting functionality
 type Limiter interface {
 	Allow() bool
+	// Tokens reports the events that could be allowed right now without
+	// consuming any of them.
+	Tokens() float64
 }
 
 type RateLimiter struct {
@@ -49,6 +53,13 @@ func (rl *RateLimiter) Allow() bool {
 	return rl.limiter.Allow()
 }
 
+func (rl *RateLimiter) Tokens() float64 {
+	if rl.limiter.Limit() == rate.Inf {
+		return math.Inf(1)
+	}
+	return rl.limiter.Tokens()
+}
+
 func (rl *RateLimiter) Wait(ctx context.Context) error {
 	return rl.limiter.Wait(ctx)
 }
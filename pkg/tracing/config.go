@@ -0,0 +1,19 @@
+package tracing
+
+const (
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+type TracingConfig struct {
+	Enabled     bool   `mapstructure:"enabled" json:"enabled" envconfig:"ENABLED"`
+	ServiceName string `mapstructure:"service_name" json:"service_name" envconfig:"SERVICE_NAME"`
+	// Exporter is stdout, to inspect traces locally without a collector, or
+	// otlp to send them over OTLP/HTTP to OTLPEndpoint, e.g. localhost:4318.
+	Exporter     string `mapstructure:"exporter" json:"exporter" envconfig:"EXPORTER"`
+	OTLPEndpoint string `mapstructure:"otlp_endpoint" json:"otlp_endpoint" envconfig:"OTLP_ENDPOINT"`
+	OTLPInsecure bool   `mapstructure:"otlp_insecure" json:"otlp_insecure" envconfig:"OTLP_INSECURE"`
+	// SampleRatio is the share of new traces recorded; traces started
+	// upstream follow the caller's sampling decision.
+	SampleRatio float64 `mapstructure:"sample_ratio" json:"sample_ratio" envconfig:"SAMPLE_RATIO"`
+}